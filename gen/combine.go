@@ -0,0 +1,267 @@
+package gen
+
+import (
+	"container/heap"
+	"context"
+)
+
+func Zip(x, y Generator) Generator {
+	return ZipWith(pair, x, y)
+}
+
+func pair(a, b interface{}) interface{} { return [2]interface{}{a, b} }
+
+func ZipWith(f func(a, b interface{}) interface{}, x, y Generator) Generator {
+	return zipper{x: x, y: y, f: f}.generator()
+}
+
+type zipper struct {
+	x    Generator
+	y    Generator
+	a    interface{}
+	held bool
+	f    func(a, b interface{}) interface{}
+}
+
+func (g zipper) generator() Generator {
+	if g.y == nil || (g.x == nil && !g.held) {
+		return nil
+	}
+	return g
+}
+
+func (g zipper) Update(ctx context.Context) Generator {
+	if g.x != nil {
+		g.x = g.x.Update(ctx)
+	}
+	if g.y != nil {
+		g.y = g.y.Update(ctx)
+	}
+	return g.generator()
+}
+
+func (g zipper) Next(ctx context.Context) (interface{}, Generator) {
+	if g.generator() == nil {
+		return StopIteration, nil
+	}
+	if !g.held {
+		a, nx := g.x.Next(ctx)
+		if IsStopIteration(a) {
+			return StopIteration, nil
+		}
+		g.x = nx
+		if IsPending(a) {
+			return a, g.generator()
+		}
+		g.a, g.held = a, true
+	}
+	b, ny := g.y.Next(ctx)
+	if IsStopIteration(b) {
+		return StopIteration, nil
+	}
+	g.y = ny
+	if IsPending(b) {
+		return b, g.generator()
+	}
+	x := g.f(g.a, b)
+	g.a, g.held = nil, false
+	return x, g.generator()
+}
+
+func Interleave(xs ...interface{}) Generator {
+	return interleave{WrapAllNonNil(xs), 0}.generator()
+}
+
+type interleave struct {
+	gs []Generator
+	i  int
+}
+
+func (g interleave) generator() Generator {
+	if len(g.gs) == 0 {
+		return nil
+	}
+	if g.i >= len(g.gs) {
+		g.i = 0
+	}
+	return g
+}
+
+func (g interleave) Update(ctx context.Context) Generator {
+	gs := make([]Generator, 0, len(g.gs))
+	i := g.i
+	for k, x := range g.gs {
+		if x = x.Update(ctx); x != nil {
+			gs = append(gs, x)
+		} else if k < g.i {
+			i--
+		}
+	}
+	return interleave{gs, i}.generator()
+}
+
+func (g interleave) Next(ctx context.Context) (interface{}, Generator) {
+	gs := append([]Generator(nil), g.gs...)
+	i := g.i
+	var pending interface{}
+	for tried := 0; tried < len(gs); {
+		x, ng := gs[i].Next(ctx)
+		if IsStopIteration(x) || ng == nil {
+			gs = append(gs[:i], gs[i+1:]...)
+		} else {
+			gs[i] = ng
+			i, tried = i+1, tried+1
+		}
+		if i >= len(gs) {
+			i = 0
+		}
+		if IsStopIteration(x) {
+			continue
+		}
+		if IsPending(x) {
+			pending = x
+			continue
+		}
+		return x, interleave{gs, i}.generator()
+	}
+	if pending != nil {
+		return pending, interleave{gs, i}.generator()
+	}
+	return StopIteration, nil
+}
+
+// OrElse yields the values of primary, or those of fallback if primary stops
+// without yielding anything. Pending values of primary are forwarded and don't
+// count as yielded values, so they never trigger the fallback on their own.
+func OrElse(primary, fallback Generator) Generator {
+	if primary == nil {
+		return fallback
+	}
+	return orElse{primary, fallback}
+}
+
+type orElse struct {
+	primary  Generator
+	fallback Generator
+}
+
+func (g orElse) Update(ctx context.Context) Generator {
+	if g.primary == nil {
+		return g.fallback
+	}
+	return OrElse(g.primary.Update(ctx), g.fallback)
+}
+
+func (g orElse) Next(ctx context.Context) (interface{}, Generator) {
+	if g.primary == nil {
+		if g.fallback == nil {
+			return StopIteration, nil
+		}
+		return g.fallback.Next(ctx)
+	}
+	x, ng := g.primary.Next(ctx)
+	if IsStopIteration(x) {
+		return OrElse(nil, g.fallback).Next(ctx)
+	}
+	if IsPending(x) {
+		return x, OrElse(ng, g.fallback)
+	}
+	return x, ng
+}
+
+// Merge merges two generators sorted in ascending order by less into one, values
+// of a come first on ties.
+func Merge(less func(a, b interface{}) bool, a, b Generator) Generator {
+	return MergeN(less, a, b)
+}
+
+func MergeN(less func(a, b interface{}) bool, gs ...Generator) Generator {
+	g := merge{less: less}
+	for i, x := range gs {
+		if x != nil {
+			g.tails = append(g.tails, mergeItem{i, nil, x})
+		}
+	}
+	return g.generator()
+}
+
+type mergeItem struct {
+	idx  int
+	head interface{}
+	tail Generator
+}
+
+type merge struct {
+	less  func(a, b interface{}) bool
+	heads []mergeItem
+	tails []mergeItem
+}
+
+func (g merge) generator() Generator {
+	if len(g.heads) == 0 && len(g.tails) == 0 {
+		return nil
+	}
+	return g
+}
+
+func (g merge) Len() int { return len(g.heads) }
+
+func (g merge) Less(i, j int) bool {
+	x, y := g.heads[i], g.heads[j]
+	return g.less(x.head, y.head) || (!g.less(y.head, x.head) && x.idx < y.idx)
+}
+
+func (g merge) Swap(i, j int) { g.heads[i], g.heads[j] = g.heads[j], g.heads[i] }
+
+func (g *merge) Push(x interface{}) { g.heads = append(g.heads, x.(mergeItem)) }
+
+func (g *merge) Pop() interface{} {
+	x := g.heads[len(g.heads)-1]
+	g.heads = g.heads[:len(g.heads)-1]
+	return x
+}
+
+func (g merge) Update(ctx context.Context) Generator {
+	heads := make([]mergeItem, 0, len(g.heads))
+	for _, x := range g.heads {
+		if x.tail != nil {
+			x.tail = x.tail.Update(ctx)
+		}
+		heads = append(heads, x)
+	}
+	tails := make([]mergeItem, 0, len(g.tails))
+	for _, x := range g.tails {
+		if x.tail = x.tail.Update(ctx); x.tail != nil {
+			tails = append(tails, x)
+		}
+	}
+	g.heads, g.tails = heads, tails
+	return g.generator()
+}
+
+func (g merge) Next(ctx context.Context) (interface{}, Generator) {
+	g.heads = append(make([]mergeItem, 0, len(g.heads)+len(g.tails)), g.heads...)
+	g.tails = append([]mergeItem(nil), g.tails...)
+	for len(g.tails) > 0 {
+		item := g.tails[0]
+		x, ng := item.tail.Next(ctx)
+		if IsPending(x) {
+			if g.tails[0].tail = ng; ng == nil {
+				g.tails = g.tails[1:]
+			}
+			return x, g.generator()
+		}
+		g.tails = g.tails[1:]
+		if !IsStopIteration(x) {
+			heap.Push(&g, mergeItem{item.idx, x, ng})
+		}
+	}
+	if len(g.heads) == 0 {
+		return StopIteration, nil
+	}
+	item := heap.Pop(&g).(mergeItem)
+	if item.tail != nil {
+		g.tails = append(g.tails, mergeItem{item.idx, nil, item.tail})
+	}
+	return item.head, g.generator()
+}
@@ -0,0 +1,162 @@
+package gen
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestZip(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		g    Generator
+		r    []interface{}
+	}{
+		{"Nil", Zip(nil, Seq(1)), nil},
+		{"Nil", Zip(Seq(1), nil), nil},
+		{"Zip", Zip(Seq(1, 2), Seq("a", "b")), []interface{}{[2]interface{}{1, "a"}, [2]interface{}{2, "b"}}},
+		{"Shorter", Zip(Seq(1, 2, 3), Seq("a")), []interface{}{[2]interface{}{1, "a"}}},
+		{"Shorter", Zip(Seq(1), Seq("a", "b")), []interface{}{[2]interface{}{1, "a"}}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.r, exhaust(tt.g))
+		})
+	}
+}
+
+func TestZipWith(t *testing.T) {
+	add := func(a, b interface{}) interface{} { return a.(int) + b.(int) }
+
+	for _, tt := range []struct {
+		name string
+		g    Generator
+		r    []interface{}
+	}{
+		{"Nil", ZipWith(add, nil, nil), nil},
+		{"Add", ZipWith(add, Seq(1, 2, 3), Seq(10, 20, 30)), []interface{}{11, 22, 33}},
+		{"Infinite", ZipWith(add, Repeat(Some(1)), Seq(10, 20)), []interface{}{11, 21}},
+		{"Pending", ZipWith(add, Seq(1, Pending, 2), Seq(10, Pending, 20)), []interface{}{11, Pending, Pending, 22}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.r, exhaust(tt.g))
+		})
+	}
+
+	t.Run("Cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		ch := make(chan interface{}, 1)
+		g := ZipWith(add, Seq(1, 2), Some(ch))
+		x, g := g.Next(ctx)
+		require.True(t, IsPending(x))
+		require.NotNil(t, g)
+		ch <- 10
+		x, g = g.Next(context.Background())
+		require.Equal(t, 11, x)
+		require.NotNil(t, g)
+	})
+}
+
+func TestInterleave(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		g    Generator
+		r    []interface{}
+	}{
+		{"Empty", Interleave(), nil},
+		{"Nil", Interleave(nil, nil), nil},
+		{"One", Interleave(Seq(1, 2)), []interface{}{1, 2}},
+		{"Interleave", Interleave(Seq(1, 2, 3), Seq("a", "b")), []interface{}{1, "a", 2, "b", 3}},
+		{"Interleave", Interleave(Seq(1, 2, 3), nil, Seq("a", "b")), []interface{}{1, "a", 2, "b", 3}},
+		{"Values", Interleave(1, Seq("a", "b"), 2), []interface{}{1, "a", 2, "b"}},
+		{"Interleave", Interleave(Seq(1), Seq("a", "b", "c"), Seq(.1, .2)), []interface{}{1, "a", .1, "b", .2, "c"}},
+		{"Infinite", Limit(4, Interleave(Repeat(Some(1)), Repeat(Some(2)))), []interface{}{1, 2, 1, 2}},
+		{"Pending", Interleave(Seq(1, Pending, 2), Seq("a", "b")), []interface{}{1, "a", "b", 2}},
+		{"Pending", Interleave(Seq(Pending, 1), Seq(Pending, "a")), []interface{}{Pending, 1, "a"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.r, exhaust(tt.g))
+		})
+	}
+
+	t.Run("Cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		ch := make(chan interface{}, 1)
+		g := Interleave(Some(ch), Seq(1, 2))
+		x, g := g.Next(ctx)
+		require.Equal(t, 1, x)
+		x, g = g.Next(ctx)
+		require.Equal(t, 2, x)
+		x, g = g.Next(ctx)
+		require.True(t, IsPending(x))
+		require.NotNil(t, g)
+		ch <- "a"
+		x, g = g.Next(context.Background())
+		require.Equal(t, "a", x)
+		require.NotNil(t, g)
+	})
+}
+
+func TestOrElse(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		g    Generator
+		r    []interface{}
+	}{
+		{"Nil", OrElse(nil, nil), nil},
+		{"Nil", OrElse(nil, Seq(1)), []interface{}{1}},
+		{"Primary", OrElse(Seq(1, 2), Seq(3)), []interface{}{1, 2}},
+		{"Fallback", OrElse(Seq(), Seq(3)), []interface{}{3}},
+		{"Fallback", OrElse(Some(closedChan()), Seq(3)), []interface{}{3}},
+		{"Pending", OrElse(Seq(Pending, 1), Seq(3)), []interface{}{Pending, 1}},
+		{"Pending", OrElse(Seq(Pending), Seq(3)), []interface{}{Pending, 3}},
+		{"Pending", OrElse(Cons(Some(Pending), Some(closedChan())), Seq(3)), []interface{}{Pending, 3}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.r, exhaust(tt.g))
+		})
+	}
+}
+
+func closedChan() chan interface{} {
+	ch := make(chan interface{})
+	close(ch)
+	return ch
+}
+
+func TestMerge(t *testing.T) {
+	less := func(a, b interface{}) bool { return a.(int) < b.(int) }
+	byKey := func(a, b interface{}) bool { return a.([2]interface{})[0].(int) < b.([2]interface{})[0].(int) }
+
+	for _, tt := range []struct {
+		name string
+		g    Generator
+		r    []interface{}
+	}{
+		{"Nil", Merge(less, nil, nil), nil},
+		{"One", Merge(less, Seq(1, 2), nil), []interface{}{1, 2}},
+		{"Merge", Merge(less, Seq(1, 4, 5), Seq(2, 3, 6, 7)), []interface{}{1, 2, 3, 4, 5, 6, 7}},
+		{"Stable", Merge(byKey, Zip(Seq(1, 2), Seq("a", "a")), Zip(Seq(1, 2), Seq("b", "b"))),
+			[]interface{}{[2]interface{}{1, "a"}, [2]interface{}{1, "b"}, [2]interface{}{2, "a"}, [2]interface{}{2, "b"}}},
+		{"Infinite", Limit(5, Merge(less, RangeInt(0, 100, 2), RangeInt(1, 100, 2))), []interface{}{0, 1, 2, 3, 4}},
+		{"Pending", Merge(less, Seq(1, Pending, 3), Seq(2)), []interface{}{1, Pending, 2, 3}},
+		{"MergeN", MergeN(less), nil},
+		{"MergeN", MergeN(less, Seq(3, 6), nil, Seq(1, 4, 7), Seq(2, 5), Seq(0)), []interface{}{0, 1, 2, 3, 4, 5, 6, 7}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.r, exhaust(tt.g))
+		})
+	}
+}
+
+func BenchmarkZipWith(b *testing.B) {
+	ctx := context.Background()
+	first := func(x, y interface{}) interface{} { return x }
+	g := ZipWith(first, Repeat(Some(0)), Repeat(Some(0)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, g = g.Next(ctx)
+	}
+}
@@ -0,0 +1,98 @@
+package gen
+
+import (
+	"context"
+	"sync"
+)
+
+// Prefetch pulls up to n values ahead from g in a background goroutine. The
+// goroutine is started by Next and runs with its ctx: it exits once g stops or
+// that ctx is done. In the latter case Next yields Pending, and a later call
+// with a live ctx starts a new goroutine that resumes g where the previous one
+// left off, so no value is lost. Cancel that ctx to release the goroutine when
+// abandoning the generator early. Because of the worker, the returned
+// generator is stateful: every value is delivered once, no matter which state
+// Next is called on.
+func Prefetch(n int, g Generator) Generator {
+	if g == nil || n <= 0 {
+		return g
+	}
+	return &prefetch{n: n, inner: g}
+}
+
+// Buffer is an alias of Prefetch with a buffer of size values. Note that the
+// worker goroutine makes the result stateful: unlike the core combinators, an
+// earlier state can't be replayed to get the same values again.
+func Buffer(size int, g Generator) Generator { return Prefetch(size, g) }
+
+type prefetch struct {
+	mu    sync.Mutex
+	n     int
+	inner Generator
+	ch    chan interface{}
+}
+
+func (g *prefetch) Update(ctx context.Context) Generator { return g }
+
+func (g *prefetch) Next(ctx context.Context) (interface{}, Generator) {
+	for {
+		g.mu.Lock()
+		if g.ch == nil {
+			if g.inner == nil {
+				g.mu.Unlock()
+				return StopIteration, nil
+			}
+			if ctx.Err() != nil {
+				g.mu.Unlock()
+				return Pending, g
+			}
+			g.ch = make(chan interface{}, g.n)
+			go g.run(ctx, g.inner, g.ch)
+			g.inner = nil
+		}
+		ch := g.ch
+		g.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return Pending, g
+		case x, ok := <-ch:
+			if ok {
+				return x, g
+			}
+			g.mu.Lock()
+			if g.ch == ch {
+				g.ch = nil
+			}
+			g.mu.Unlock()
+		}
+	}
+}
+
+// run sends the values of inner to ch until it stops or ctx is done, and then
+// leaves what remains of inner for the next worker before closing ch.
+func (g *prefetch) run(ctx context.Context, inner Generator, ch chan interface{}) {
+	defer func() {
+		g.mu.Lock()
+		g.inner = inner
+		g.mu.Unlock()
+		close(ch)
+	}()
+	for inner != nil {
+		x, ng := inner.Next(ctx)
+		if IsStopIteration(x) {
+			inner = nil
+			return
+		}
+		if IsPending(x) && ctx.Err() != nil {
+			inner = ng
+			return
+		}
+		select {
+		case <-ctx.Done():
+			inner = Cons(FromSlice([]interface{}{x}), ng)
+			return
+		case ch <- x:
+		}
+		inner = ng
+	}
+}
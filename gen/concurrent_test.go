@@ -0,0 +1,93 @@
+package gen
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func requireGoroutines(t *testing.T, n int) {
+	for i := 0; i < 100 && runtime.NumGoroutine() > n; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	require.LessOrEqual(t, runtime.NumGoroutine(), n)
+}
+
+func TestPrefetch(t *testing.T) {
+	require.Nil(t, Prefetch(1, nil))
+	g := Seq(1)
+	require.Equal(t, g, Prefetch(0, g))
+
+	require.Equal(t, []interface{}{1, Pending, 2, 3}, exhaust(Prefetch(2, Seq(1, Pending, 2, 3))))
+
+	t.Run("Infinite", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		require.Equal(t, []interface{}{0, 1, 2}, ToSlice(ctx, Limit(3, Prefetch(2, naturals()))))
+	})
+
+	t.Run("Ahead", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		n := 0
+		g := Prefetch(3, Some(func() interface{} {
+			n++
+			return n
+		}))
+		x, g := g.Next(ctx)
+		require.Equal(t, 1, x)
+		for i := 0; i < 100 && len(g.(*prefetch).ch) < 3; i++ {
+			time.Sleep(time.Millisecond)
+		}
+		require.Len(t, g.(*prefetch).ch, 3)
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		n := runtime.NumGoroutine()
+		ctx, cancel := context.WithCancel(context.Background())
+		g := Prefetch(1, Repeat(Some(1)))
+		x, g := g.Next(ctx)
+		require.Equal(t, 1, x)
+		cancel()
+		requireGoroutines(t, n)
+		ctx, cancel = context.WithCancel(context.Background())
+		defer cancel()
+		require.Equal(t, []interface{}{1, 1, 1}, ToSlice(ctx, Limit(3, g)))
+	})
+
+	t.Run("Resume", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		g := Prefetch(2, Seq(1, 2, 3))
+		x, g := g.Next(ctx)
+		require.True(t, IsPending(x))
+		require.Equal(t, []interface{}{1, 2, 3}, ToSlice(context.Background(), g))
+
+		n := runtime.NumGoroutine()
+		ctx, cancel = context.WithCancel(context.Background())
+		g = Prefetch(1, Seq(1, 2, 3, 4, 5))
+		x, g = g.Next(ctx)
+		require.Equal(t, 1, x)
+		cancel()
+		requireGoroutines(t, n)
+		require.Equal(t, []interface{}{2, 3, 4, 5}, ToSlice(context.Background(), g))
+	})
+}
+
+func TestBuffer(t *testing.T) {
+	require.Nil(t, Buffer(1, nil))
+	require.Equal(t, []interface{}{1, 2, 3}, exhaust(Buffer(2, Seq(1, 2, 3))))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	g := Buffer(2, Seq(1, 2, 3))
+	x, ng := g.Next(ctx)
+	require.Equal(t, 1, x)
+	x, _ = g.Next(ctx)
+	require.Equal(t, 2, x)
+	x, _ = ng.Next(ctx)
+	require.Equal(t, 3, x)
+}
@@ -3,6 +3,7 @@ package gen
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math"
 	"math/rand"
 	"reflect"
@@ -34,12 +35,22 @@ type Generator interface {
 }
 
 func AsChannel(ctx context.Context, g Generator) <-chan interface{} {
-	ch := make(chan interface{})
+	return AsChannelBuffered(ctx, g, 0)
+}
+
+// AsChannelBuffered is like AsChannel but returns a channel with a buffer of
+// size buf. The goroutine feeding the channel exits and closes it once g stops
+// or ctx is done, so cancel ctx when abandoning the channel before it's closed.
+func AsChannelBuffered(ctx context.Context, g Generator, buf int) <-chan interface{} {
+	if buf < 0 {
+		buf = 0
+	}
+	ch := make(chan interface{}, buf)
 	go func() {
 		defer close(ch)
 		var x interface{}
 		for {
-			if g == nil {
+			if g == nil || ctx.Err() != nil {
 				return
 			}
 			x, g = g.Next(ctx)
@@ -114,20 +125,39 @@ func (g some) Update(ctx context.Context) Generator { return g }
 
 func (g some) Next(ctx context.Context) (interface{}, Generator) { return g.val, nil }
 
+// Const yields val forever. Unlike Some, val is yielded verbatim even if it is
+// a func or a channel.
+func Const(val interface{}) Generator { return constant{val} }
+
+type constant struct{ val interface{} }
+
+func (g constant) Update(ctx context.Context) Generator { return g }
+
+func (g constant) Next(ctx context.Context) (interface{}, Generator) { return g.val, g }
+
 type ch <-chan interface{}
 
 func (g ch) Update(ctx context.Context) Generator { return g }
 
 func (g ch) Next(ctx context.Context) (interface{}, Generator) {
+	select {
+	case x, ok := <-g:
+		return g.received(x, ok)
+	default:
+	}
 	select {
 	case <-ctx.Done():
 		return Pending, g
 	case x, ok := <-g:
-		if ok {
-			return x, g
-		}
-		return StopIteration, nil
+		return g.received(x, ok)
+	}
+}
+
+func (g ch) received(x interface{}, ok bool) (interface{}, Generator) {
+	if ok {
+		return x, g
 	}
+	return StopIteration, nil
 }
 
 type fn0 func() interface{}
@@ -214,6 +244,12 @@ func (g cons) Next(ctx context.Context) (interface{}, Generator) {
 		return g.tail.Next(ctx)
 	}
 	x, ng := g.head.Next(ctx)
+	if IsStopIteration(x) {
+		if g.tail == nil {
+			return StopIteration, nil
+		}
+		return g.tail.Next(ctx)
+	}
 	if ng == nil {
 		ng = g.tail
 	} else {
@@ -230,6 +266,23 @@ func Seq(xs ...interface{}) Generator {
 	return seq(gs)
 }
 
+// Concat yields the values of each generator in turn. Unlike Seq, the
+// arguments are used as generators directly instead of being wrapped by Some.
+func Concat(gs ...Generator) Generator { return ConcatSlice(gs) }
+
+func ConcatSlice(gs []Generator) Generator {
+	out := make(seq, 0, len(gs))
+	for _, g := range gs {
+		if g != nil {
+			out = append(out, g)
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
 type seq []Generator
 
 func (gs seq) Update(ctx context.Context) Generator {
@@ -254,42 +307,51 @@ func (gs seq) Next(ctx context.Context) (interface{}, Generator) {
 	return StopIteration, nil
 }
 
-func Mix(xs ...interface{}) Generator {
+func Mix(xs ...interface{}) Generator { return MixRand(nil, xs...) }
+
+func MixRand(r *rand.Rand, xs ...interface{}) Generator {
 	gs := WrapAllNonNil(xs)
 	if len(gs) == 0 {
 		return nil
 	}
-	return mix(gs)
+	return mix{gs, r}
 }
 
-type mix []Generator
+type mix struct {
+	gs []Generator
+	r  *rand.Rand
+}
 
-func (gs mix) Update(ctx context.Context) Generator {
-	ng := UpdateAll(ctx, gs)
+func (g mix) Update(ctx context.Context) Generator {
+	ng := UpdateAll(ctx, g.gs)
 	if len(ng) == 0 {
 		return nil
 	}
-	return mix(ng)
+	return mix{ng, g.r}
 }
 
-func (gs mix) Next(ctx context.Context) (interface{}, Generator) {
-	alts := make([]Generator, 0, len(gs))
-	for _, g := range gs {
-		if g == nil {
+func (g mix) Next(ctx context.Context) (interface{}, Generator) {
+	alts := append([]Generator(nil), g.gs...)
+	r := randFrom(ctx, g.r)
+	for len(alts) > 0 {
+		i := randIntn(r, len(alts))
+		x, ng := alts[i].Next(ctx)
+		if ng == nil {
+			last := len(alts) - 1
+			alts[i], alts[last] = alts[last], nil
+			alts = alts[:last]
+		} else {
+			alts[i] = ng
+		}
+		if IsStopIteration(x) {
 			continue
 		}
-		alts = append(alts, g)
-	}
-	if len(alts) == 0 {
-		return StopIteration, nil
-	}
-	i := rand.Intn(len(alts))
-	x, ng := alts[i].Next(ctx)
-	if len(alts) == 1 && ng == nil {
-		return x, nil
+		if len(alts) == 0 {
+			return x, nil
+		}
+		return x, mix{alts, g.r}
 	}
-	alts[i] = ng
-	return x, mix(alts)
+	return StopIteration, nil
 }
 
 func Map(f func(x interface{}) interface{}, g Generator) Generator {
@@ -336,6 +398,21 @@ func Filter(f func(x interface{}) bool, g Generator) Generator {
 	}, g)
 }
 
+// FilterMap yields v for each value of g for which f returns (v, true), and
+// drops the value otherwise. Pending values are passed through without calling
+// f.
+func FilterMap(f func(x interface{}) (interface{}, bool), g Generator) Generator {
+	return FlatMap(func(x interface{}) Generator {
+		if IsPending(x) {
+			return some{x}
+		}
+		if v, ok := f(x); ok {
+			return some{v}
+		}
+		return nil
+	}, g)
+}
+
 type flatMapper struct {
 	inner Generator
 	f     func(interface{}) Generator
@@ -353,10 +430,16 @@ func (g flatMapper) Next(ctx context.Context) (interface{}, Generator) {
 		return StopIteration, nil
 	}
 	x, ng := g.inner.Next(ctx)
+	if IsStopIteration(x) {
+		return StopIteration, nil
+	}
 	if ng != nil {
 		ng = FlatMap(g.f, ng)
 	}
-	return Cons(g.f(x), ng).Next(ctx)
+	if ng = Cons(g.f(x), ng); ng == nil {
+		return StopIteration, nil
+	}
+	return ng.Next(ctx)
 }
 
 func Once(g Generator) Generator { return Limit(1, g) }
@@ -388,6 +471,10 @@ func (g limit) Next(ctx context.Context) (interface{}, Generator) {
 	return x, Limit(g.remaining-1, ng)
 }
 
+// Repeat replays g from its initial state forever. A cycle restarts whenever
+// the current one is exhausted, either by yielding its last value, by stopping
+// or by being updated to nil. It stops only if g stops without yielding
+// anything.
 func Repeat(g Generator) Generator {
 	if g == nil {
 		return nil
@@ -414,15 +501,91 @@ func (g repeat) Next(ctx context.Context) (interface{}, Generator) {
 	if g.orig == nil {
 		return StopIteration, nil
 	}
-	if g.iter == nil {
+	fresh := g.iter == nil
+	if fresh {
 		g.iter = g.orig
 	}
 	x, iter := g.iter.Next(ctx)
+	if IsStopIteration(x) {
+		if fresh {
+			return StopIteration, nil
+		}
+		return repeat{g.orig, nil}.Next(ctx)
+	}
 	return x, repeat{g.orig, iter}
 }
 
-func RangeI64(args ...int64) Generator {
-	g := rangeI64{0, math.MaxInt64, 1}
+// Cycle replays g from its initial state the given number of times and then
+// stops.
+func Cycle(times int, g Generator) Generator {
+	if g == nil || times <= 0 {
+		return nil
+	}
+	return cycle{g, g, times, true}
+}
+
+// RepeatN is an alias of Cycle.
+func RepeatN(n int, g Generator) Generator { return Cycle(n, g) }
+
+type cycle struct {
+	orig  Generator
+	iter  Generator
+	times int
+	fresh bool
+}
+
+func (g cycle) generator() Generator {
+	if g.iter == nil {
+		if g.times--; g.times <= 0 {
+			return nil
+		}
+		g.iter, g.fresh = g.orig, true
+	}
+	return g
+}
+
+func (g cycle) Update(ctx context.Context) Generator {
+	if g.iter != nil {
+		g.iter = g.iter.Update(ctx)
+	}
+	return g.generator()
+}
+
+func (g cycle) Next(ctx context.Context) (interface{}, Generator) {
+	for g.iter != nil {
+		x, ng := g.iter.Next(ctx)
+		if IsStopIteration(x) {
+			if g.fresh {
+				break
+			}
+			g.iter = nil
+			ng = g.generator()
+			if ng == nil {
+				break
+			}
+			g = ng.(cycle)
+			continue
+		}
+		g.iter, g.fresh = ng, g.fresh && IsPending(x)
+		return x, g.generator()
+	}
+	return StopIteration, nil
+}
+
+func RangeI64(args ...int64) Generator { return newRangeI64(math.MaxInt64, args) }
+
+const maxInt = int(^uint(0) >> 1)
+
+func RangeInt(args ...int) Generator {
+	xs := make([]int64, len(args))
+	for i, x := range args {
+		xs[i] = int64(x)
+	}
+	return Map(func(x interface{}) interface{} { return int(x.(int64)) }, newRangeI64(int64(maxInt), xs))
+}
+
+func newRangeI64(end int64, args []int64) Generator {
+	g := rangeI64{0, end, 1}
 	if len(args) == 0 {
 	} else if len(args) == 1 {
 		g.start = args[0]
@@ -460,6 +623,9 @@ func (g rangeI64) Next(ctx context.Context) (interface{}, Generator) {
 	if !g.hasNext() {
 		return StopIteration, nil
 	}
+	if (g.step > 0 && g.start > math.MaxInt64-g.step) || (g.step < 0 && g.start < math.MinInt64-g.step) {
+		return g.start, nil
+	}
 	ng := rangeI64{g.start + g.step, g.end, g.step}
 	if !ng.hasNext() {
 		return g.start, nil
@@ -467,8 +633,18 @@ func (g rangeI64) Next(ctx context.Context) (interface{}, Generator) {
 	return g.start, ng
 }
 
-func RangeF64(args ...float64) Generator {
-	g := rangeF64{0, math.MaxFloat64, 1}
+func RangeF64(args ...float64) Generator { return newRangeF64(math.MaxFloat64, args) }
+
+func RangeFloat32(args ...float32) Generator {
+	xs := make([]float64, len(args))
+	for i, x := range args {
+		xs[i] = float64(x)
+	}
+	return Map(func(x interface{}) interface{} { return float32(x.(float64)) }, newRangeF64(math.MaxFloat32, xs))
+}
+
+func newRangeF64(end float64, args []float64) Generator {
+	g := rangeF64{0, end, 1}
 	if len(args) == 0 {
 	} else if len(args) == 1 {
 		g.start = args[0]
@@ -518,10 +694,55 @@ type GeneratorWithProb struct {
 	Prob float64
 }
 
-func (g GeneratorWithProb) Valid() bool { return g.Prob > 0 && g.Generator != nil }
+// Valid reports whether g can be chosen, i.e. it is non-nil and its
+// probability is positive and finite. NaN and negative probabilities are
+// invalid.
+func (g GeneratorWithProb) Valid() bool {
+	return g.Prob > 0 && !math.IsInf(g.Prob, 1) && g.Generator != nil
+}
 
 type Choices []GeneratorWithProb
 
+// Normalize returns a copy of gs with the probabilities of valid entries
+// rescaled to sum to 1.
+func (gs Choices) Normalize() Choices {
+	s := .0
+	for _, g := range gs {
+		if g.Valid() {
+			s += g.Prob
+		}
+	}
+	out := make(Choices, len(gs))
+	for i, g := range gs {
+		if g.Valid() {
+			g.Prob /= s
+		}
+		out[i] = g
+	}
+	return out
+}
+
+// NormalizedChoices returns gs.Normalize().
+func NormalizedChoices(gs Choices) Choices { return gs.Normalize() }
+
+// Validate reports NaN, infinite or negative probabilities, and choices
+// without any valid entry.
+func (gs Choices) Validate() error {
+	n := 0
+	for i, g := range gs {
+		if math.IsNaN(g.Prob) || math.IsInf(g.Prob, 0) || g.Prob < 0 {
+			return fmt.Errorf("invalid probability of choice #%d: %v", i, g.Prob)
+		}
+		if g.Valid() {
+			n++
+		}
+	}
+	if n == 0 {
+		return errors.New("no valid choice")
+	}
+	return nil
+}
+
 func (gs Choices) Update(ctx context.Context) Generator {
 	out := make(Choices, 0, len(gs))
 	for _, g := range gs {
@@ -539,6 +760,14 @@ func (gs Choices) Update(ctx context.Context) Generator {
 }
 
 func (gs Choices) Next(ctx context.Context) (interface{}, Generator) {
+	x, ngs := gs.next(ctx, nil)
+	if len(ngs) == 0 {
+		return x, nil
+	}
+	return x, ngs
+}
+
+func (gs Choices) next(ctx context.Context, r *rand.Rand) (interface{}, Choices) {
 	n, s := 0, .0
 	for _, g := range gs {
 		if g.Valid() {
@@ -549,7 +778,7 @@ func (gs Choices) Next(ctx context.Context) (interface{}, Generator) {
 	if n == 0 {
 		return StopIteration, nil
 	}
-	t := rand.Float64() * s
+	t := randFloat64(randFrom(ctx, r)) * s
 	ngs := make(Choices, 0, n)
 	var (
 		x  interface{}
@@ -571,22 +800,134 @@ func (gs Choices) Next(ctx context.Context) (interface{}, Generator) {
 			ngs = append(ngs, g)
 		}
 	}
+	return x, ngs
+}
+
+func ChoicesRand(r *rand.Rand, gs Choices) Generator {
+	if len(gs) == 0 {
+		return nil
+	}
+	return randChoices{gs, r}
+}
+
+type randChoices struct {
+	gs Choices
+	r  *rand.Rand
+}
+
+func (g randChoices) Update(ctx context.Context) Generator {
+	if ng, ok := g.gs.Update(ctx).(Choices); ok {
+		return randChoices{ng, g.r}
+	}
+	return nil
+}
+
+func (g randChoices) Next(ctx context.Context) (interface{}, Generator) {
+	x, ngs := g.gs.next(ctx, g.r)
 	if len(ngs) == 0 {
 		return x, nil
 	}
-	return x, ngs
+	return x, randChoices{ngs, g.r}
+}
+
+type GeneratorWithWeight struct {
+	Generator
+	Weight func(ctx context.Context) float64
+}
+
+// WeightedChoices is like Choices, but weights are evaluated on every Next, so
+// the odds of each branch can change over time. Branches with a non-positive
+// weight are skipped without being dropped, and Next returns Pending when no
+// branch can be chosen at the moment. An exhausted branch is dropped and
+// another one is chosen, so StopIteration is only returned once all are done.
+type WeightedChoices []GeneratorWithWeight
+
+func (gs WeightedChoices) Update(ctx context.Context) Generator {
+	out := make(WeightedChoices, 0, len(gs))
+	for _, g := range gs {
+		if g.Generator == nil || g.Weight == nil {
+			continue
+		}
+		if ng := g.Update(ctx); ng != nil {
+			out = append(out, GeneratorWithWeight{ng, g.Weight})
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func (gs WeightedChoices) Next(ctx context.Context) (interface{}, Generator) {
+	alts := make(WeightedChoices, 0, len(gs))
+	for _, g := range gs {
+		if g.Generator != nil && g.Weight != nil {
+			alts = append(alts, g)
+		}
+	}
+	r := randFrom(ctx, nil)
+	for len(alts) > 0 {
+		s, ws := .0, make([]float64, len(alts))
+		for i, g := range alts {
+			if w := g.Weight(ctx); w > 0 && !math.IsInf(w, 0) {
+				ws[i] = w
+				s += w
+			}
+		}
+		if s == 0 {
+			return Pending, alts
+		}
+		t, k := randFloat64(r)*s, -1
+		for i, w := range ws {
+			if w == 0 {
+				continue
+			}
+			if k = i; t < w {
+				break
+			}
+			t -= w
+		}
+		x, ng := alts[k].Next(ctx)
+		if ng == nil {
+			alts = append(alts[:k], alts[k+1:]...)
+		} else {
+			alts[k].Generator = ng
+		}
+		if IsStopIteration(x) {
+			continue
+		}
+		if len(alts) == 0 {
+			return x, nil
+		}
+		return x, alts
+	}
+	return StopIteration, nil
 }
 
 func TimeLimit(d time.Duration, g Generator) Generator {
 	if g == nil || d <= 0 {
 		return nil
 	}
-	return timeLimit{g, time.After(d)}
+	return timeLimit{inner: g, ch: time.After(d)}
+}
+
+// TimeLimitReset is like TimeLimit, but once its deadline has passed, Update
+// starts a fresh deadline of d instead of keeping the expired one, so the
+// generator can be reused for another run. Updates before the deadline keep
+// it, like TimeLimit does, so the limit also holds when driven by terminals
+// such as ToSlice, which call Update after every Pending.
+func TimeLimitReset(d time.Duration, g Generator) Generator {
+	if g == nil || d <= 0 {
+		return nil
+	}
+	return timeLimit{g, time.After(d), d, time.Now().Add(d)}
 }
 
 type timeLimit struct {
 	inner Generator
 	ch    <-chan time.Time
+	reset time.Duration
+	at    time.Time
 }
 
 func (g timeLimit) Update(ctx context.Context) Generator {
@@ -597,7 +938,11 @@ func (g timeLimit) Update(ctx context.Context) Generator {
 	if ng == nil {
 		return nil
 	}
-	return timeLimit{ng, g.ch}
+	if g.reset > 0 && !time.Now().Before(g.at) {
+		return TimeLimitReset(g.reset, ng)
+	}
+	g.inner = ng
+	return g
 }
 
 func (g timeLimit) Next(ctx context.Context) (interface{}, Generator) {
@@ -612,19 +957,30 @@ func (g timeLimit) Next(ctx context.Context) (interface{}, Generator) {
 	default:
 		x, ng := g.inner.Next(ctx)
 		if ng != nil {
-			ng = timeLimit{ng, g.ch}
+			g.inner = ng
+			ng = g
 		}
 		return x, ng
 	}
 }
 
-func Stagger(d time.Duration, g Generator) Generator {
+func Stagger(d time.Duration, g Generator) Generator { return StaggerRand(nil, d, g) }
+
+func StaggerRand(r *rand.Rand, d time.Duration, g Generator) Generator {
 	if d <= 0 {
 		return g
 	}
-	return StaggerFn(func() <-chan time.Time {
-		return time.After(time.Duration(rand.Int63n(d.Nanoseconds() * 2)))
-	}, g)
+	n := int64(math.MaxInt64)
+	if d <= math.MaxInt64/2 {
+		n = d.Nanoseconds() * 2
+	}
+	if g == nil {
+		return nil
+	}
+	return stagger{g, nil, time.Time{}, func(ctx context.Context) (<-chan time.Time, time.Time) {
+		d := time.Duration(randInt63n(randFrom(ctx, r), n))
+		return time.After(d), time.Now().Add(d)
+	}}
 }
 
 func StaggerFn(f func() <-chan time.Time, g Generator) Generator {
@@ -634,15 +990,26 @@ func StaggerFn(f func() <-chan time.Time, g Generator) Generator {
 	if f == nil {
 		return g
 	}
-	return stagger{g, nil, f}
+	return stagger{g, nil, time.Time{}, func(context.Context) (<-chan time.Time, time.Time) { return f(), time.Time{} }}
+}
+
+// Deadliner is implemented by generators that know when their next value is
+// due, e.g. the ones returned by Stagger.
+type Deadliner interface {
+	// Deadline returns the time at which the pending timer fires, ok is false if
+	// no timer is armed yet or its deadline is unknown (see StaggerFn).
+	Deadline() (deadline time.Time, ok bool)
 }
 
 type stagger struct {
 	inner Generator
 	ch    <-chan time.Time
-	f     func() <-chan time.Time
+	at    time.Time
+	f     func(context.Context) (<-chan time.Time, time.Time)
 }
 
+func (g stagger) Deadline() (time.Time, bool) { return g.at, g.ch != nil && !g.at.IsZero() }
+
 func (g stagger) Update(ctx context.Context) Generator {
 	if g.inner == nil {
 		return nil
@@ -651,7 +1018,7 @@ func (g stagger) Update(ctx context.Context) Generator {
 	if ng == nil {
 		return nil
 	}
-	return stagger{ng, g.ch, g.f}
+	return stagger{ng, g.ch, g.at, g.f}
 }
 
 func (g stagger) Next(ctx context.Context) (interface{}, Generator) {
@@ -659,7 +1026,7 @@ func (g stagger) Next(ctx context.Context) (interface{}, Generator) {
 		return StopIteration, nil
 	}
 	if g.ch == nil {
-		g.ch = g.f()
+		g.ch, g.at = g.f(ctx)
 	}
 	select {
 	case <-ctx.Done():
@@ -667,7 +1034,8 @@ func (g stagger) Next(ctx context.Context) (interface{}, Generator) {
 	case <-g.ch:
 		x, ng := g.inner.Next(ctx)
 		if ng != nil {
-			ng = stagger{ng, g.f(), g.f}
+			ch, at := g.f(ctx)
+			ng = stagger{ng, ch, at, g.f}
 		}
 		return x, ng
 	}
@@ -5,6 +5,7 @@ import (
 	"math"
 	"math/rand"
 	"reflect"
+	"runtime"
 	"sort"
 	"strconv"
 	"testing"
@@ -13,10 +14,6 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func init() {
-	rand.Seed(time.Now().UnixNano())
-}
-
 func exhaust(g Generator) []interface{} {
 	var xs []interface{}
 	for x := range AsChannel(context.TODO(), g) {
@@ -25,6 +22,32 @@ func exhaust(g Generator) []interface{} {
 	return xs
 }
 
+func TestAsChannel(t *testing.T) {
+	ctx := context.Background()
+	ch := AsChannelBuffered(ctx, Seq(1, 2, 3), 2)
+	require.Equal(t, 2, cap(ch))
+	for i := 0; i < 100 && len(ch) < 2; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	require.Len(t, ch, 2)
+	var xs []interface{}
+	for x := range ch {
+		xs = append(xs, x)
+	}
+	require.Equal(t, []interface{}{1, 2, 3}, xs)
+
+	t.Run("Cancel", func(t *testing.T) {
+		n := runtime.NumGoroutine()
+		ctx, cancel := context.WithCancel(context.Background())
+		ch1 := AsChannel(ctx, Repeat(Some(1)))
+		ch2 := AsChannelBuffered(ctx, Repeat(Some(1)), 4)
+		<-ch1
+		<-ch2
+		cancel()
+		requireGoroutines(t, n)
+	})
+}
+
 func TestNone(t *testing.T) {
 	require.Nil(t, None())
 }
@@ -126,6 +149,11 @@ func TestCons(t *testing.T) {
 	require.Equal(t, []interface{}{42, 42, 42}, exhaust(Cons(gg, g)))
 	require.Equal(t, []interface{}{42, 42, 42}, exhaust(Cons(g, gg)))
 	require.Equal(t, []interface{}{42, 42, 42, 42}, exhaust(Cons(gg, gg)))
+
+	ch := make(chan interface{}, 1)
+	ch <- 1
+	close(ch)
+	require.Equal(t, []interface{}{1, 42}, exhaust(Cons(Some(ch), g)))
 }
 
 func TestSeq(t *testing.T) {
@@ -170,6 +198,20 @@ func TestMix(t *testing.T) {
 	t.Fatal("shouldn't reach here")
 }
 
+func TestMixExhausted(t *testing.T) {
+	ctx := context.Background()
+	g := Mix(Some(closedChan()), Seq(1, 2), 3)
+	ys := exhaust(g)
+	sort.Slice(ys, func(i, j int) bool { return ys[i].(int) < ys[j].(int) })
+	require.Equal(t, []interface{}{1, 2, 3}, ys)
+
+	g = Mix(1, 2, Repeat(Some(0)))
+	for i := 0; i < 100; i++ {
+		_, g = g.Next(ctx)
+	}
+	require.Len(t, g.(mix).gs, 1)
+}
+
 func TestMap(t *testing.T) {
 	id := func(x interface{}) interface{} { return x }
 	pending := func(x interface{}) interface{} { return Pending }
@@ -222,6 +264,31 @@ func TestFlatMap(t *testing.T) {
 	}
 }
 
+func TestFilterMap(t *testing.T) {
+	half := func(x interface{}) (interface{}, bool) {
+		n := x.(int)
+		return n / 2, n%2 == 0
+	}
+
+	for _, tt := range []struct {
+		name string
+		g    Generator
+		r    []interface{}
+	}{
+		{"Nil", FilterMap(half, nil), nil},
+		{"FilterMap", FilterMap(half, Seq(1, 2, 3, 4)), []interface{}{1, 2}},
+		{"None", FilterMap(half, Seq(1, 3)), nil},
+		{"Filter", Filter(func(x interface{}) bool { return false }, Seq(1)), nil},
+		{"Infinite", Limit(3, FilterMap(half, naturals())), []interface{}{0, 1, 2}},
+		{"Pending", FilterMap(half, Seq(1, Pending, 2)), []interface{}{Pending, 1}},
+		{"Verbatim", FilterMap(func(x interface{}) (interface{}, bool) { return nil, true }, Seq(1)), []interface{}{nil}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.r, exhaust(tt.g))
+		})
+	}
+}
+
 func TestLimit(t *testing.T) {
 	for _, tt := range []struct {
 		name string
@@ -250,6 +317,69 @@ func TestRepeat(t *testing.T) {
 		{"Nil", Limit(2, Repeat(nil)), nil},
 		{"Repeat", Limit(3, Repeat(Some(1))), []interface{}{1, 1, 1}},
 		{"Repeat", Limit(5, Repeat(Seq(1, 2))), []interface{}{1, 2, 1, 2, 1}},
+		{"Repeat", Limit(7, Repeat(Seq(1, 2, 3))), []interface{}{1, 2, 3, 1, 2, 3, 1}},
+		{"Stop", Limit(3, Repeat(Cons(Some(1), Some(closedChan())))), []interface{}{1, 1, 1}},
+		{"Empty", Repeat(Some(closedChan())), nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.r, exhaust(tt.g))
+		})
+	}
+
+	t.Run("Update", func(t *testing.T) {
+		ctx := context.Background()
+		g := Repeat(Seq(1, Choices{{Some(2), 0}}))
+		x, g := g.Next(ctx)
+		require.Equal(t, 1, x)
+		g = g.Update(ctx)
+		require.NotNil(t, g)
+		x, _ = g.Next(ctx)
+		require.Equal(t, 1, x)
+	})
+}
+
+func TestConcat(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		g    Generator
+		r    []interface{}
+	}{
+		{"Empty", Concat(), nil},
+		{"Nil", Concat(nil, nil), nil},
+		{"Concat", Concat(Seq(1, 2), Seq(3, 4)), []interface{}{1, 2, 3, 4}},
+		{"Concat", Concat(Seq(1), nil, Seq(Pending, 2), Some(closedChan()), Seq(3)), []interface{}{1, Pending, 2, 3}},
+		{"Infinite", Limit(3, Concat(Seq(1), Repeat(Some(2)))), []interface{}{1, 2, 2}},
+		{"Slice", ConcatSlice([]Generator{Seq(1, 2), Seq(3)}), []interface{}{1, 2, 3}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.r, exhaust(tt.g))
+		})
+	}
+}
+
+func TestConst(t *testing.T) {
+	require.Equal(t, []interface{}{1, 1, 1}, exhaust(Limit(3, Const(1))))
+	require.Equal(t, []interface{}{nil, nil}, exhaust(Limit(2, Const(nil))))
+	ch := make(chan interface{})
+	require.Equal(t, []interface{}{ch}, exhaust(Limit(1, Const(ch))))
+}
+
+func TestCycle(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		g    Generator
+		r    []interface{}
+	}{
+		{"Nil", Cycle(2, nil), nil},
+		{"Zero", Cycle(0, Seq(1)), nil},
+		{"Once", Cycle(1, Seq(1, 2)), []interface{}{1, 2}},
+		{"Cycle", Cycle(3, Seq(1, 2)), []interface{}{1, 2, 1, 2, 1, 2}},
+		{"Pending", Cycle(2, Seq(1, Pending)), []interface{}{1, Pending, 1, Pending}},
+		{"Channel", Cycle(2, Some(closedChan())), nil},
+		{"Channel", Cycle(2, Cons(Some(1), Some(closedChan()))), []interface{}{1, 1}},
+		{"Limit", Limit(3, Cycle(10, Repeat(Some(1)))), []interface{}{1, 1, 1}},
+		{"RepeatN", RepeatN(0, Seq(1, 2)), nil},
+		{"RepeatN", RepeatN(2, Seq(1, 2)), []interface{}{1, 2, 1, 2}},
 	} {
 		t.Run(tt.name, func(t *testing.T) {
 			require.Equal(t, tt.r, exhaust(tt.g))
@@ -279,6 +409,11 @@ func TestRangeI64(t *testing.T) {
 		{RangeI64(2, 1, 1), nil},
 		{RangeI64(6, 1, -2), i64s(6, 4, 2)},
 		{Limit(3, RangeI64(-1, -2, 0)), i64s(-1, -1, -1)},
+		{Limit(3, RangeI64(math.MaxInt64-1, math.MaxInt64, 1)), i64s(math.MaxInt64 - 1)},
+		{Limit(3, RangeI64(math.MaxInt64-3, math.MaxInt64, 2)), i64s(math.MaxInt64-3, math.MaxInt64-1)},
+		{Limit(3, RangeI64(math.MaxInt64-1, math.MaxInt64, math.MaxInt64)), i64s(math.MaxInt64 - 1)},
+		{Limit(3, RangeI64(math.MinInt64+3, math.MinInt64, -2)), i64s(math.MinInt64+3, math.MinInt64+1)},
+		{Limit(3, RangeI64(math.MinInt64+1, math.MinInt64, math.MinInt64)), i64s(math.MinInt64 + 1)},
 	} {
 		t.Run(strconv.Itoa(i), func(t *testing.T) {
 			require.Equal(t, tt.r, exhaust(tt.g))
@@ -316,6 +451,44 @@ func TestRangeF64(t *testing.T) {
 	}
 }
 
+func TestRangeInt(t *testing.T) {
+	for i, tt := range []struct {
+		g Generator
+		r []interface{}
+	}{
+		{Limit(3, RangeInt()), []interface{}{0, 1, 2}},
+		{RangeInt(1, 6, 2), []interface{}{1, 3, 5}},
+		{RangeInt(6, 1, -2), []interface{}{6, 4, 2}},
+		{RangeInt(1, 2, -1), nil},
+		{RangeInt(1, 2, 3, 4), nil},
+		{Limit(3, RangeInt(1, 2, 0)), []interface{}{1, 1, 1}},
+		{Limit(3, RangeInt(maxInt-1)), []interface{}{maxInt - 1}},
+		{Limit(3, RangeInt(-maxInt, -maxInt-1, -1)), []interface{}{-maxInt}},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			require.Equal(t, tt.r, exhaust(tt.g))
+		})
+	}
+}
+
+func TestRangeFloat32(t *testing.T) {
+	for i, tt := range []struct {
+		g Generator
+		r []interface{}
+	}{
+		{Limit(3, RangeFloat32()), []interface{}{float32(0), float32(1), float32(2)}},
+		{RangeFloat32(1, 2, .5), []interface{}{float32(1), float32(1.5)}},
+		{RangeFloat32(2, 1, -.5), []interface{}{float32(2), float32(1.5)}},
+		{RangeFloat32(1, 2, -1), nil},
+		{Limit(2, RangeFloat32(1, 2, 0)), []interface{}{float32(1), float32(1)}},
+		{Limit(3, RangeFloat32(math.MaxFloat32)), nil},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			require.Equal(t, tt.r, exhaust(tt.g))
+		})
+	}
+}
+
 func TestChoices(t *testing.T) {
 	ctx := context.Background()
 
@@ -349,11 +522,11 @@ func TestChoices(t *testing.T) {
 	})
 
 	t.Run("Distribution", func(t *testing.T) {
-		g := Limit(1000, Choices{
+		g := Limit(1000, ChoicesRand(rand.New(rand.NewSource(1)), Choices{
 			{Some(func() interface{} { return 0 }), 2},
 			{Some(func() interface{} { return 1 }), 3},
 			{Some(func() interface{} { return 2 }), 5},
-		})
+		}))
 		var cnts [3]float64
 		for _, x := range exhaust(g) {
 			cnts[x.(int)] += 1
@@ -365,6 +538,117 @@ func TestChoices(t *testing.T) {
 
 }
 
+func TestChoicesNormalize(t *testing.T) {
+	g := Some(1)
+	require.Equal(t, Choices{}, Choices{}.Normalize())
+	require.Equal(t,
+		Choices{{g, .25}, {nil, 1}, {g, 0}, {g, .75}},
+		Choices{{g, 1}, {nil, 1}, {g, 0}, {g, 3}}.Normalize())
+
+	gs := Choices{{g, 2}}
+	require.Equal(t, Choices{{g, 1}}, gs.Normalize())
+	require.Equal(t, Choices{{g, 2}}, gs)
+
+	gs = Choices{{g, 1}, {g, -1}, {g, math.NaN()}, {g, math.Inf(1)}, {g, 1}}
+	ns := NormalizedChoices(gs)
+	require.Equal(t, .5, ns[0].Prob)
+	require.Equal(t, -1., ns[1].Prob)
+	require.True(t, math.IsNaN(ns[2].Prob))
+	require.True(t, math.IsInf(ns[3].Prob, 1))
+	require.Equal(t, .5, ns[4].Prob)
+}
+
+func TestChoicesInvalid(t *testing.T) {
+	require.Equal(t, []interface{}{1, 1, 1}, exhaust(Limit(3, Choices{
+		{Repeat(Some(0)), -1},
+		{Repeat(Some(1)), 1},
+		{Repeat(Some(2)), math.NaN()},
+		{Repeat(Some(3)), math.Inf(1)},
+	})))
+	require.Nil(t, exhaust(Choices{{Some(1), -1}, {Some(2), math.NaN()}, {Some(3), 0}}))
+	x, g := Choices{{Some(1), -1}, {Some(2), 0}}.Next(context.Background())
+	require.True(t, IsStopIteration(x))
+	require.Nil(t, g)
+}
+
+func TestChoicesValidate(t *testing.T) {
+	g := Some(1)
+	for i, tt := range []struct {
+		ok bool
+		gs Choices
+	}{
+		{true, Choices{{g, .2}, {g, 0}, {nil, 1}}},
+		{false, Choices{}},
+		{false, Choices{{g, 0}, {nil, 1}}},
+		{false, Choices{{g, 1}, {g, math.NaN()}}},
+		{false, Choices{{g, 1}, {g, math.Inf(1)}}},
+		{false, Choices{{g, 1}, {g, -1}}},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if tt.ok {
+				require.NoError(t, tt.gs.Validate())
+			} else {
+				require.Error(t, tt.gs.Validate())
+			}
+		})
+	}
+}
+
+func TestWeightedChoices(t *testing.T) {
+	ctx := context.Background()
+	weight := func(w float64) func(context.Context) float64 {
+		return func(context.Context) float64 { return w }
+	}
+
+	t.Run("Empty", func(t *testing.T) {
+		x, g := WeightedChoices{{nil, weight(1)}, {Some(1), nil}}.Next(ctx)
+		require.True(t, IsStopIteration(x))
+		require.Nil(t, g)
+		require.Nil(t, WeightedChoices{{nil, weight(1)}}.Update(ctx))
+	})
+
+	t.Run("Exhaust", func(t *testing.T) {
+		g := WeightedChoices{{Seq(1, 3), weight(1)}, {Seq(2, 4), weight(1)}}
+		ys := exhaust(g)
+		sort.Slice(ys, func(i, j int) bool { return ys[i].(int) < ys[j].(int) })
+		require.Equal(t, []interface{}{1, 2, 3, 4}, ys)
+	})
+
+	t.Run("DrainedBranch", func(t *testing.T) {
+		g := WeightedChoices{{Some(closedChan()), weight(1)}, {Seq(1, 2, 3), weight(1)}}
+		require.Equal(t, []interface{}{1, 2, 3}, exhaust(g))
+	})
+
+	t.Run("Dynamic", func(t *testing.T) {
+		n := 0
+		ramp := func(context.Context) float64 { return float64(n) }
+		g := Generator(WeightedChoices{
+			{Repeat(Some(0)), weight(math.NaN())},
+			{Repeat(Some(1)), ramp},
+			{Repeat(Some(2)), func(context.Context) float64 { return float64(1 - n) }},
+		})
+		var x interface{}
+		for i := 0; i < 10; i++ {
+			x, g = g.Next(ctx)
+			require.Equal(t, 2, x)
+		}
+		n = 1
+		for i := 0; i < 10; i++ {
+			x, g = g.Next(ctx)
+			require.Equal(t, 1, x)
+		}
+		n = -1
+		for i := 0; i < 10; i++ {
+			x, g = g.Next(ctx)
+			require.Equal(t, 2, x)
+		}
+
+		x, g = WeightedChoices{{Repeat(Some(0)), weight(0)}}.Next(ctx)
+		require.True(t, IsPending(x))
+		require.NotNil(t, g)
+	})
+}
+
 func TestTimeLimit(t *testing.T) {
 
 	t.Run("Nil", func(t *testing.T) {
@@ -388,6 +672,37 @@ func TestTimeLimit(t *testing.T) {
 		require.True(t, IsStopIteration(x))
 		require.Nil(t, g)
 	})
+
+	t.Run("Reset", func(t *testing.T) {
+		ctx := context.Background()
+		require.Nil(t, TimeLimitReset(time.Second, nil))
+
+		g := TimeLimitReset(10*time.Millisecond, Repeat(Some(1)))
+		x, g := g.Next(ctx)
+		require.Equal(t, 1, x)
+		time.Sleep(20 * time.Millisecond)
+		x, _ = g.Next(ctx)
+		require.True(t, IsStopIteration(x))
+		x, _ = g.Update(ctx).Next(ctx)
+		require.Equal(t, 1, x)
+
+		g = TimeLimit(10*time.Millisecond, Repeat(Some(1)))
+		time.Sleep(20 * time.Millisecond)
+		x, _ = g.Update(ctx).Next(ctx)
+		require.True(t, IsStopIteration(x))
+	})
+
+	t.Run("ResetByTerminal", func(t *testing.T) {
+		ctx := context.Background()
+		slow := Limit(1000, Repeat(Concat(Limit(1, Some(func() interface{} {
+			time.Sleep(2 * time.Millisecond)
+			return 1
+		})), Some(Pending))))
+		start := time.Now()
+		n := len(ToSlice(ctx, TimeLimitReset(50*time.Millisecond, slow)))
+		require.Less(t, n, 100)
+		require.True(t, time.Since(start) < 500*time.Millisecond)
+	})
 }
 
 func TestStagger(t *testing.T) {
@@ -398,6 +713,42 @@ func TestStagger(t *testing.T) {
 		require.InDelta(t, 200, size, 20)
 	})
 
+	t.Run("Large", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		for _, d := range []time.Duration{math.MaxInt64 / 2, math.MaxInt64/2 + 1, math.MaxInt64} {
+			x, g := Stagger(d, Some(1)).Next(ctx)
+			require.True(t, IsPending(x))
+			require.NotNil(t, g)
+		}
+	})
+
+	t.Run("Deadline", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		g := Stagger(time.Hour, Seq(1, 2))
+		_, ok := g.(Deadliner).Deadline()
+		require.False(t, ok)
+		start := time.Now()
+		x, g := g.Next(ctx)
+		require.True(t, IsPending(x))
+		at, ok := g.(Deadliner).Deadline()
+		require.True(t, ok)
+		require.False(t, at.Before(start))
+		require.False(t, at.After(start.Add(2*time.Hour)))
+
+		x, g = Stagger(time.Millisecond, Seq(1, 2)).Next(context.Background())
+		require.Equal(t, 1, x)
+		_, ok = g.(Deadliner).Deadline()
+		require.True(t, ok)
+
+		ticks := time.NewTicker(time.Millisecond)
+		defer ticks.Stop()
+		_, g = StaggerFn(func() <-chan time.Time { return ticks.C }, Seq(1, 2)).Next(context.Background())
+		_, ok = g.(Deadliner).Deadline()
+		require.False(t, ok)
+	})
+
 	t.Run("StaggerFn", func(t *testing.T) {
 		ticks := time.NewTicker(time.Millisecond)
 		defer ticks.Stop()
@@ -408,6 +759,19 @@ func TestStagger(t *testing.T) {
 
 }
 
+func BenchmarkMix1000(b *testing.B) {
+	ctx := context.Background()
+	xs := make([]interface{}, 1000)
+	for i := range xs {
+		xs[i] = i
+	}
+	for i := 0; i < b.N; i++ {
+		for g := Mix(xs...); g != nil; {
+			_, g = g.Next(ctx)
+		}
+	}
+}
+
 func BenchmarkMap(b *testing.B) {
 	ctx := context.Background()
 	id := func(x interface{}) interface{} { return x }
@@ -429,6 +793,22 @@ func BenchmarkMapByFlatMap(b *testing.B) {
 	}
 }
 
+func BenchmarkConst(b *testing.B) {
+	ctx := context.Background()
+	g := Const(1)
+	for i := 0; i < b.N; i++ {
+		_, g = g.Next(ctx)
+	}
+}
+
+func BenchmarkRepeatSome(b *testing.B) {
+	ctx := context.Background()
+	g := Repeat(Some(1))
+	for i := 0; i < b.N; i++ {
+		_, g = g.Next(ctx)
+	}
+}
+
 func BenchmarkStaggerRepeat(b *testing.B) {
 	ctx := context.Background()
 	g := Stagger(time.Millisecond, Repeat(Some(1)))
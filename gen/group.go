@@ -0,0 +1,193 @@
+package gen
+
+import (
+	"context"
+	"reflect"
+)
+
+func Chunk(n int, g Generator) Generator {
+	if g == nil || n <= 0 {
+		return nil
+	}
+	return chunk{g, n, nil}
+}
+
+type chunk struct {
+	inner Generator
+	n     int
+	buf   []interface{}
+}
+
+func (g chunk) generator() Generator {
+	if g.inner == nil && len(g.buf) == 0 {
+		return nil
+	}
+	return g
+}
+
+func (g chunk) Update(ctx context.Context) Generator {
+	if g.inner != nil {
+		g.inner = g.inner.Update(ctx)
+	}
+	return g.generator()
+}
+
+func (g chunk) Next(ctx context.Context) (interface{}, Generator) {
+	buf := make([]interface{}, len(g.buf), g.n)
+	copy(buf, g.buf)
+	inner := g.inner
+	for inner != nil && len(buf) < g.n {
+		x, ng := inner.Next(ctx)
+		if IsStopIteration(x) {
+			inner = nil
+			break
+		}
+		inner = ng
+		if IsPending(x) {
+			return x, chunk{inner, g.n, buf}.generator()
+		}
+		buf = append(buf, x)
+	}
+	if len(buf) == 0 {
+		return StopIteration, nil
+	}
+	return buf, chunk{inner, g.n, nil}.generator()
+}
+
+func Window(size int, g Generator) Generator { return WindowStep(size, 1, g) }
+
+func WindowStep(size int, step int, g Generator) Generator {
+	if g == nil || size <= 0 || step <= 0 {
+		return nil
+	}
+	return window{inner: g, size: size, step: step}
+}
+
+// MovingAverage yields the mean of the last window numeric values of g as
+// float64, starting once window values have been seen. Values of non-numeric
+// kinds are ignored.
+func MovingAverage(window int, g Generator) Generator {
+	numeric := FilterMap(func(x interface{}) (interface{}, bool) { return toFloat64(x) }, g)
+	return FilterMap(func(x interface{}) (interface{}, bool) {
+		s := .0
+		for _, f := range x.([]interface{}) {
+			s += f.(float64)
+		}
+		return s / float64(window), true
+	}, Window(window, numeric))
+}
+
+type window struct {
+	inner Generator
+	size  int
+	step  int
+	buf   []interface{}
+	skip  int
+}
+
+func (g window) generator() Generator {
+	if g.inner == nil {
+		return nil
+	}
+	return g
+}
+
+func (g window) Update(ctx context.Context) Generator {
+	if g.inner != nil {
+		g.inner = g.inner.Update(ctx)
+	}
+	return g.generator()
+}
+
+func (g window) Next(ctx context.Context) (interface{}, Generator) {
+	buf := make([]interface{}, len(g.buf), g.size)
+	copy(buf, g.buf)
+	for len(buf) < g.size {
+		if g.inner == nil {
+			return StopIteration, nil
+		}
+		x, ng := g.inner.Next(ctx)
+		if IsStopIteration(x) {
+			return StopIteration, nil
+		}
+		g.inner = ng
+		if IsPending(x) {
+			g.buf = buf
+			return x, g.generator()
+		}
+		if g.skip > 0 {
+			g.skip--
+			continue
+		}
+		buf = append(buf, x)
+	}
+	if g.step < g.size {
+		g.buf = append([]interface{}(nil), buf[g.step:]...)
+	} else {
+		g.buf, g.skip = nil, g.step-g.size
+	}
+	return buf, g.generator()
+}
+
+// GroupByRuns groups consecutive values of g with equal keys (by
+// reflect.DeepEqual) into slices. A run is emitted once a value with another
+// key arrives or g stops.
+func GroupByRuns(key func(x interface{}) interface{}, g Generator) Generator {
+	if g == nil {
+		return nil
+	}
+	return runs{inner: g, key: key}
+}
+
+// GroupByAdjacent is an alias of GroupByRuns.
+func GroupByAdjacent(key func(x interface{}) interface{}, g Generator) Generator {
+	return GroupByRuns(key, g)
+}
+
+type runs struct {
+	inner Generator
+	key   func(interface{}) interface{}
+	buf   []interface{}
+	k     interface{}
+}
+
+func (g runs) generator() Generator {
+	if g.inner == nil && len(g.buf) == 0 {
+		return nil
+	}
+	return g
+}
+
+func (g runs) Update(ctx context.Context) Generator {
+	if g.inner != nil {
+		g.inner = g.inner.Update(ctx)
+	}
+	return g.generator()
+}
+
+func (g runs) Next(ctx context.Context) (interface{}, Generator) {
+	buf := append([]interface{}(nil), g.buf...)
+	for g.inner != nil {
+		x, ng := g.inner.Next(ctx)
+		if IsStopIteration(x) {
+			g.inner = nil
+			break
+		}
+		g.inner = ng
+		if IsPending(x) {
+			g.buf = buf
+			return x, g.generator()
+		}
+		k := g.key(x)
+		if len(buf) > 0 && !reflect.DeepEqual(k, g.k) {
+			g.buf, g.k = []interface{}{x}, k
+			return buf, g.generator()
+		}
+		buf, g.k = append(buf, x), k
+	}
+	if len(buf) == 0 {
+		return StopIteration, nil
+	}
+	g.buf = nil
+	return buf, g.generator()
+}
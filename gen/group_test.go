@@ -0,0 +1,124 @@
+package gen
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunk(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		g    Generator
+		r    []interface{}
+	}{
+		{"Nil", Chunk(2, nil), nil},
+		{"Zero", Chunk(0, Seq(1, 2)), nil},
+		{"Even", Chunk(2, Seq(1, 2, 3, 4)), []interface{}{[]interface{}{1, 2}, []interface{}{3, 4}}},
+		{"Short", Chunk(2, Seq(1, 2, 3)), []interface{}{[]interface{}{1, 2}, []interface{}{3}}},
+		{"Infinite", Limit(2, Chunk(3, naturals())), []interface{}{[]interface{}{0, 1, 2}, []interface{}{3, 4, 5}}},
+		{"Pending", Chunk(2, Seq(1, Pending, 2, 3, Pending)), []interface{}{Pending, []interface{}{1, 2}, Pending, []interface{}{3}}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.r, exhaust(tt.g))
+		})
+	}
+
+	t.Run("Partial", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		ch := make(chan interface{}, 3)
+		ch <- 1
+		g := Chunk(2, Some(ch))
+		x, g := g.Next(ctx)
+		require.True(t, IsPending(x))
+		require.NotNil(t, g)
+
+		ch <- 2
+		ch <- 3
+		x, ng := g.Next(context.Background())
+		require.Equal(t, []interface{}{1, 2}, x)
+		require.NotNil(t, ng)
+
+		close(ch)
+		x, _ = g.Next(context.Background())
+		require.Equal(t, []interface{}{1, 3}, x)
+	})
+}
+
+func TestWindow(t *testing.T) {
+	xs := func(xs ...interface{}) []interface{} { return xs }
+
+	for _, tt := range []struct {
+		name string
+		g    Generator
+		r    []interface{}
+	}{
+		{"Nil", Window(2, nil), nil},
+		{"Zero", Window(0, Seq(1, 2)), nil},
+		{"Short", Window(3, Seq(1, 2)), nil},
+		{"Window", Window(2, Seq(1, 2, 3, 4)), xs(xs(1, 2), xs(2, 3), xs(3, 4))},
+		{"Infinite", Limit(2, Window(3, naturals())), xs(xs(0, 1, 2), xs(1, 2, 3))},
+		{"Pending", Window(2, Seq(1, Pending, 2, 3)), xs(Pending, xs(1, 2), xs(2, 3))},
+		{"Step", WindowStep(3, 2, Seq(1, 2, 3, 4, 5, 6)), xs(xs(1, 2, 3), xs(3, 4, 5))},
+		{"Step", WindowStep(2, 2, Seq(1, 2, 3, 4, 5)), xs(xs(1, 2), xs(3, 4))},
+		{"Step", WindowStep(2, 3, Seq(1, 2, 3, 4, 5, 6, 7)), xs(xs(1, 2), xs(4, 5))},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.r, exhaust(tt.g))
+		})
+	}
+
+	t.Run("Mutate", func(t *testing.T) {
+		ctx := context.Background()
+		x, g := WindowStep(3, 1, Seq(1, 2, 3, 4, 5)).Next(ctx)
+		x.([]interface{})[1] = 99
+		x, _ = g.Next(ctx)
+		require.Equal(t, xs(2, 3, 4), x)
+	})
+}
+
+func TestMovingAverage(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		g    Generator
+		r    []interface{}
+	}{
+		{"Nil", MovingAverage(2, nil), nil},
+		{"Zero", MovingAverage(0, Seq(1, 2)), nil},
+		{"Short", MovingAverage(3, Seq(1, 2)), nil},
+		{"Average", MovingAverage(2, Seq(1, 2, 3, 5)), []interface{}{1.5, 2.5, 4.}},
+		{"Numeric", MovingAverage(2, Seq(1, "x", int64(2), nil, float32(4))), []interface{}{1.5, 3.}},
+		{"Infinite", Limit(3, MovingAverage(3, naturals())), []interface{}{1., 2., 3.}},
+		{"Pending", MovingAverage(2, Seq(1, Pending, 2, 3)), []interface{}{Pending, 1.5, 2.5}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.r, exhaust(tt.g))
+		})
+	}
+}
+
+func TestGroupByRuns(t *testing.T) {
+	xs := func(xs ...interface{}) []interface{} { return xs }
+	parity := func(x interface{}) interface{} { return x.(int) % 2 }
+
+	for _, tt := range []struct {
+		name string
+		g    Generator
+		r    []interface{}
+	}{
+		{"Nil", GroupByRuns(parity, nil), nil},
+		{"One", GroupByRuns(parity, Seq(1)), xs(xs(1))},
+		{"Runs", GroupByRuns(parity, Seq(1, 3, 2, 4, 6, 5)), xs(xs(1, 3), xs(2, 4, 6), xs(5))},
+		{"Identity", GroupByRuns(identity, Seq("a", "a", "b", "a")), xs(xs("a", "a"), xs("b"), xs("a"))},
+		{"Infinite", Limit(2, GroupByRuns(func(x interface{}) interface{} { return x.(int) / 3 }, naturals())), xs(xs(0, 1, 2), xs(3, 4, 5))},
+		{"Adjacent", GroupByAdjacent(identity, Seq(1, 1, 2, 3, 3, 3)), xs(xs(1, 1), xs(2), xs(3, 3, 3))},
+		{"Pending", GroupByRuns(parity, Seq(1, Pending, 3, 2, Pending)), xs(Pending, xs(1, 3), Pending, xs(2))},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.r, exhaust(tt.g))
+		})
+	}
+}
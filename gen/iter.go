@@ -0,0 +1,48 @@
+//go:build go1.23
+// +build go1.23
+
+package gen
+
+import (
+	"context"
+	"iter"
+)
+
+// Iter returns an iterator over the values of g for use with range-over-func.
+// Pending values are skipped as in ToSlice, and the iteration ends when g stops
+// or ctx is done. Breaking out of the loop stops pulling from g.
+func Iter(ctx context.Context, g Generator) iter.Seq[interface{}] {
+	return func(yield func(interface{}) bool) {
+		drive(ctx, g, yield)
+	}
+}
+
+// AsIterator is an alias of Iter, mirroring AsChannel without its goroutine.
+func AsIterator(ctx context.Context, g Generator) iter.Seq[interface{}] { return Iter(ctx, g) }
+
+// FromIter returns a generator yielding the values of seq. Like one made from a
+// channel, it is stateful: values are pulled from seq as Next is called, and
+// seq stays suspended until it is drained.
+func FromIter(seq iter.Seq[interface{}]) Generator {
+	if seq == nil {
+		return nil
+	}
+	next, stop := iter.Pull(seq)
+	return pull{next, stop}
+}
+
+type pull struct {
+	next func() (interface{}, bool)
+	stop func()
+}
+
+func (g pull) Update(ctx context.Context) Generator { return g }
+
+func (g pull) Next(ctx context.Context) (interface{}, Generator) {
+	x, ok := g.next()
+	if !ok {
+		g.stop()
+		return StopIteration, nil
+	}
+	return x, g
+}
@@ -0,0 +1,61 @@
+//go:build go1.23
+// +build go1.23
+
+package gen
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIter(t *testing.T) {
+	ctx := context.Background()
+
+	var xs []interface{}
+	for x := range Iter(ctx, Seq(1, Pending, 2, 3)) {
+		xs = append(xs, x)
+	}
+	require.Equal(t, []interface{}{1, 2, 3}, xs)
+
+	n := 0
+	g := Map(func(x interface{}) interface{} {
+		n++
+		return x
+	}, Repeat(Some(1)))
+	for x := range Iter(ctx, g) {
+		require.Equal(t, 1, x)
+		if n == 3 {
+			break
+		}
+	}
+	require.Equal(t, 3, n)
+
+	for range Iter(ctx, nil) {
+		t.Fatal("shouldn't reach here")
+	}
+}
+
+func TestAsIterator(t *testing.T) {
+	var xs []interface{}
+	for x := range AsIterator(context.Background(), Seq(1, Pending, 2)) {
+		xs = append(xs, x)
+	}
+	require.Equal(t, exhaust(Filter(func(x interface{}) bool { return !IsPending(x) }, Seq(1, Pending, 2))), xs)
+}
+
+func TestFromIter(t *testing.T) {
+	seq := func(yield func(interface{}) bool) {
+		for i := 0; i < 3; i++ {
+			if !yield(i) {
+				return
+			}
+		}
+	}
+
+	require.Nil(t, FromIter(nil))
+	require.Equal(t, []interface{}{0, 1, 2}, exhaust(FromIter(seq)))
+	require.Equal(t, []interface{}{0, 2}, exhaust(Filter(func(x interface{}) bool { return x.(int)%2 == 0 }, FromIter(seq))))
+	require.Equal(t, []interface{}{1, 2, 3}, ToSlice(context.Background(), FromIter(Iter(context.Background(), Seq(1, 2, 3)))))
+}
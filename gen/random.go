@@ -0,0 +1,238 @@
+package gen
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Generators accepting a *rand.Rand fall back to the one attached to the
+// context passed to Next (see WithRand), and then to the global source when it
+// is nil. Note that a *rand.Rand is not safe for concurrent use.
+
+type randKey struct{}
+
+func WithRand(ctx context.Context, r *rand.Rand) context.Context {
+	return context.WithValue(ctx, randKey{}, r)
+}
+
+func RandFromContext(ctx context.Context) *rand.Rand {
+	r, _ := ctx.Value(randKey{}).(*rand.Rand)
+	return r
+}
+
+func randFrom(ctx context.Context, r *rand.Rand) *rand.Rand {
+	if r == nil && ctx != nil {
+		return RandFromContext(ctx)
+	}
+	return r
+}
+
+func randIntn(r *rand.Rand, n int) int {
+	if r == nil {
+		return rand.Intn(n)
+	}
+	return r.Intn(n)
+}
+
+func randInt63n(r *rand.Rand, n int64) int64 {
+	if r == nil {
+		return rand.Int63n(n)
+	}
+	return r.Int63n(n)
+}
+
+func randFloat64(r *rand.Rand) float64 {
+	if r == nil {
+		return rand.Float64()
+	}
+	return r.Float64()
+}
+
+func randNormFloat64(r *rand.Rand) float64 {
+	if r == nil {
+		return rand.NormFloat64()
+	}
+	return r.NormFloat64()
+}
+
+// Normal yields normally distributed float64 values, forever. It returns nil if
+// stddev is negative.
+func Normal(mean, stddev float64) Generator { return NormalRand(nil, mean, stddev) }
+
+func NormalRand(r *rand.Rand, mean, stddev float64) Generator {
+	if !(stddev >= 0) {
+		return nil
+	}
+	return normal{mean, stddev, r}
+}
+
+type normal struct {
+	mean   float64
+	stddev float64
+	r      *rand.Rand
+}
+
+func (g normal) Update(ctx context.Context) Generator { return g }
+
+func (g normal) Next(ctx context.Context) (interface{}, Generator) {
+	return g.mean + g.stddev*randNormFloat64(randFrom(ctx, g.r)), g
+}
+
+// Uniform yields float64 values uniformly distributed in [lo, hi), forever. It
+// returns nil if lo > hi.
+func Uniform(lo, hi float64) Generator { return UniformRand(nil, lo, hi) }
+
+func UniformRand(r *rand.Rand, lo, hi float64) Generator {
+	if !(lo <= hi) {
+		return nil
+	}
+	return uniform{lo, hi, r}
+}
+
+type uniform struct {
+	lo float64
+	hi float64
+	r  *rand.Rand
+}
+
+func (g uniform) Update(ctx context.Context) Generator { return g }
+
+func (g uniform) Next(ctx context.Context) (interface{}, Generator) {
+	return g.lo + (g.hi-g.lo)*randFloat64(randFrom(ctx, g.r)), g
+}
+
+// WeightedSample yields items picked at random proportional to weights,
+// forever. It returns nil if the lengths mismatch, or if any weight is negative
+// or not finite, or if they sum to 0.
+func WeightedSample(items []interface{}, weights []float64) Generator {
+	return WeightedSampleRand(nil, items, weights)
+}
+
+func WeightedSampleRand(r *rand.Rand, items []interface{}, weights []float64) Generator {
+	if len(items) == 0 || len(items) != len(weights) {
+		return nil
+	}
+	cum, s := make([]float64, len(weights)), .0
+	for i, w := range weights {
+		if math.IsNaN(w) || math.IsInf(w, 0) || w < 0 {
+			return nil
+		}
+		s += w
+		cum[i] = s
+	}
+	if s <= 0 || math.IsInf(s, 0) {
+		return nil
+	}
+	return weightedSample{items, cum, r}
+}
+
+type weightedSample struct {
+	items []interface{}
+	cum   []float64
+	r     *rand.Rand
+}
+
+func (g weightedSample) Update(ctx context.Context) Generator { return g }
+
+func (g weightedSample) Next(ctx context.Context) (interface{}, Generator) {
+	n := len(g.cum)
+	t := randFloat64(randFrom(ctx, g.r)) * g.cum[n-1]
+	i := sort.Search(n, func(i int) bool { return g.cum[i] > t })
+	for i == n || (i > 0 && g.cum[i] == g.cum[i-1]) {
+		i--
+	}
+	return g.items[i], g
+}
+
+const alphanumeric = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// RandomString yields random strings whose length is uniformly distributed in
+// [minLen, maxLen] (in runes), drawn from the runes of alphabet, or [a-zA-Z0-9]
+// if it is empty. It returns nil if minLen < 0 or maxLen < minLen.
+func RandomString(minLen, maxLen int, alphabet string) Generator {
+	return RandomStringRand(nil, minLen, maxLen, alphabet)
+}
+
+func RandomStringRand(r *rand.Rand, minLen, maxLen int, alphabet string) Generator {
+	if minLen < 0 || maxLen < minLen {
+		return nil
+	}
+	if len(alphabet) == 0 {
+		alphabet = alphanumeric
+	}
+	return randomString{minLen, maxLen, []rune(alphabet), r}
+}
+
+type randomString struct {
+	min      int
+	max      int
+	alphabet []rune
+	r        *rand.Rand
+}
+
+func (g randomString) Update(ctx context.Context) Generator { return g }
+
+func (g randomString) Next(ctx context.Context) (interface{}, Generator) {
+	r := randFrom(ctx, g.r)
+	buf := make([]rune, g.min+randIntn(r, g.max-g.min+1))
+	for i := range buf {
+		buf[i] = g.alphabet[randIntn(r, len(g.alphabet))]
+	}
+	return string(buf), g
+}
+
+// Shuffle yields the values of g in random order. It drains g on the first
+// call to Next and buffers all its values, so g must be finite. Pending values
+// of g are forwarded while draining.
+func Shuffle(g Generator) Generator { return ShuffleRand(nil, g) }
+
+func ShuffleRand(r *rand.Rand, g Generator) Generator {
+	if g == nil {
+		return nil
+	}
+	return shuffle{g, nil, r}
+}
+
+type shuffle struct {
+	inner Generator
+	xs    []interface{}
+	r     *rand.Rand
+}
+
+func (g shuffle) Update(ctx context.Context) Generator {
+	if g.inner != nil {
+		g.inner = g.inner.Update(ctx)
+	}
+	if g.inner == nil && len(g.xs) == 0 {
+		return nil
+	}
+	return g
+}
+
+func (g shuffle) Next(ctx context.Context) (interface{}, Generator) {
+	xs := append([]interface{}(nil), g.xs...)
+	for g.inner != nil {
+		x, ng := g.inner.Next(ctx)
+		if IsStopIteration(x) {
+			break
+		}
+		if IsPending(x) {
+			return x, shuffle{ng, xs, g.r}
+		}
+		xs = append(xs, x)
+		g.inner = ng
+	}
+	r := randFrom(ctx, g.r)
+	swap := func(i, j int) { xs[i], xs[j] = xs[j], xs[i] }
+	if r == nil {
+		rand.Shuffle(len(xs), swap)
+	} else {
+		r.Shuffle(len(xs), swap)
+	}
+	if len(xs) == 0 {
+		return StopIteration, nil
+	}
+	return FromSlice(xs).Next(ctx)
+}
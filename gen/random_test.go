@@ -0,0 +1,156 @@
+package gen
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMixRand(t *testing.T) {
+	xs := []interface{}{1, 2, 3, 4, 5, 6, 7, 8}
+	require.Nil(t, MixRand(rand.New(rand.NewSource(1))))
+	require.Equal(t,
+		exhaust(MixRand(rand.New(rand.NewSource(42)), xs...)),
+		exhaust(MixRand(rand.New(rand.NewSource(42)), xs...)))
+}
+
+func TestChoicesRand(t *testing.T) {
+	choices := func() Choices {
+		return Choices{
+			{Repeat(Some(0)), 1},
+			{Repeat(Some(1)), 2},
+			{Seq(2, 2), 3},
+		}
+	}
+	require.Nil(t, ChoicesRand(rand.New(rand.NewSource(1)), nil))
+	xs := exhaust(Limit(100, ChoicesRand(rand.New(rand.NewSource(42)), choices())))
+	require.Len(t, xs, 100)
+	require.Equal(t, xs, exhaust(Limit(100, ChoicesRand(rand.New(rand.NewSource(42)), choices()))))
+
+	g := ChoicesRand(rand.New(rand.NewSource(1)), Choices{{Seq(1, 2), 1}, {nil, 1}})
+	require.Equal(t, []interface{}{1, 2}, exhaust(g))
+	require.Nil(t, ChoicesRand(nil, Choices{{Seq(1), 0}}).Update(context.Background()))
+}
+
+func TestStaggerRand(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	start := time.Now()
+	require.Equal(t, []interface{}{1, 2, 3}, exhaust(StaggerRand(r, time.Millisecond, Seq(1, 2, 3))))
+	require.Less(t, int64(time.Since(start)), int64(time.Second))
+}
+
+func TestWeightedSample(t *testing.T) {
+	require.Nil(t, WeightedSample(nil, nil))
+	require.Nil(t, WeightedSample([]interface{}{1}, []float64{1, 2}))
+	require.Nil(t, WeightedSample([]interface{}{1, 2}, []float64{1, -1}))
+	require.Nil(t, WeightedSample([]interface{}{1, 2}, []float64{1, math.NaN()}))
+	require.Nil(t, WeightedSample([]interface{}{1, 2}, []float64{0, 0}))
+
+	require.Equal(t, []interface{}{2, 2, 2}, exhaust(Limit(3, WeightedSample([]interface{}{1, 2, 3}, []float64{0, 1, 0}))))
+
+	items, weights := []interface{}{"a", "b", "c"}, []float64{1, 2, 7}
+	xs := exhaust(Limit(1000, WeightedSampleRand(rand.New(rand.NewSource(42)), items, weights)))
+	require.Equal(t, xs, exhaust(Limit(1000, WeightedSampleRand(rand.New(rand.NewSource(42)), items, weights))))
+	cnt := map[interface{}]int{}
+	for _, x := range xs {
+		cnt[x]++
+	}
+	require.InDelta(t, 100, cnt["a"], 50)
+	require.InDelta(t, 200, cnt["b"], 50)
+	require.InDelta(t, 700, cnt["c"], 50)
+}
+
+func TestNormal(t *testing.T) {
+	require.Nil(t, Normal(0, -1))
+	require.Nil(t, Normal(0, math.NaN()))
+	require.Equal(t, []interface{}{1.5, 1.5}, exhaust(Limit(2, Normal(1.5, 0))))
+
+	xs := exhaust(Limit(1000, NormalRand(rand.New(rand.NewSource(42)), 10, 2)))
+	require.Equal(t, xs, exhaust(Limit(1000, NormalRand(rand.New(rand.NewSource(42)), 10, 2))))
+	s := .0
+	for _, x := range xs {
+		s += x.(float64)
+	}
+	require.InDelta(t, 10, s/1000, .5)
+}
+
+func TestUniform(t *testing.T) {
+	require.Nil(t, Uniform(1, 0))
+	require.Nil(t, Uniform(math.NaN(), 0))
+	require.Equal(t, []interface{}{1., 1.}, exhaust(Limit(2, Uniform(1, 1))))
+
+	xs := exhaust(Limit(1000, UniformRand(rand.New(rand.NewSource(42)), -1, 3)))
+	require.Equal(t, xs, exhaust(Limit(1000, UniformRand(rand.New(rand.NewSource(42)), -1, 3))))
+	for _, x := range xs {
+		require.GreaterOrEqual(t, x.(float64), -1.)
+		require.Less(t, x.(float64), 3.)
+	}
+}
+
+func TestRandomString(t *testing.T) {
+	require.Nil(t, RandomString(-1, 1, ""))
+	require.Nil(t, RandomString(2, 1, ""))
+	require.Equal(t, []interface{}{"", ""}, exhaust(Limit(2, RandomString(0, 0, "ab"))))
+	require.Equal(t, []interface{}{"ééé"}, exhaust(Limit(1, RandomString(3, 3, "é"))))
+
+	xs := exhaust(Limit(100, RandomStringRand(rand.New(rand.NewSource(42)), 1, 4, "")))
+	require.Equal(t, xs, exhaust(Limit(100, RandomStringRand(rand.New(rand.NewSource(42)), 1, 4, ""))))
+	for _, x := range xs {
+		s := x.(string)
+		require.GreaterOrEqual(t, len(s), 1)
+		require.LessOrEqual(t, len(s), 4)
+		for _, c := range s {
+			require.Contains(t, alphanumeric, string(c))
+		}
+	}
+}
+
+func TestShuffle(t *testing.T) {
+	require.Nil(t, Shuffle(nil))
+	require.Nil(t, exhaust(Shuffle(Seq())))
+	require.Equal(t, []interface{}{1}, exhaust(Shuffle(Seq(1))))
+
+	xs := exhaust(ShuffleRand(rand.New(rand.NewSource(42)), RangeInt(0, 20)))
+	require.Equal(t, xs, exhaust(ShuffleRand(rand.New(rand.NewSource(42)), RangeInt(0, 20))))
+	require.NotEqual(t, ToSlice(context.Background(), RangeInt(0, 20)), xs)
+	require.ElementsMatch(t, ToSlice(context.Background(), RangeInt(0, 20)), xs)
+
+	ys := exhaust(ShuffleRand(rand.New(rand.NewSource(1)), Seq(1, Pending, 2, 3)))
+	require.Equal(t, Pending, ys[0])
+	require.ElementsMatch(t, []interface{}{1, 2, 3}, ys[1:])
+
+	t.Run("TrailingPending", func(t *testing.T) {
+		ctx := context.Background()
+		in := []interface{}{1, 2, 3, 4, 5, 6, 7, 8}
+		for i := int64(0); i < 3; i++ {
+			xs := ToSlice(ctx, ShuffleRand(rand.New(rand.NewSource(i)), Seq(append(in, Pending)...)))
+			require.ElementsMatch(t, in, xs)
+			require.NotEqual(t, in, xs)
+		}
+	})
+}
+
+func TestRandFromContext(t *testing.T) {
+	ctx := context.Background()
+	require.Nil(t, RandFromContext(ctx))
+	r := rand.New(rand.NewSource(1))
+	require.Equal(t, r, RandFromContext(WithRand(ctx, r)))
+
+	run := func(g Generator) []interface{} {
+		return ToSlice(WithRand(ctx, rand.New(rand.NewSource(42))), g)
+	}
+	xs := []interface{}{1, 2, 3, 4, 5, 6, 7, 8}
+	require.Equal(t, run(Mix(xs...)), run(Mix(xs...)))
+
+	choices := func() Generator {
+		return Limit(100, Choices{{Repeat(Some(0)), 1}, {Repeat(Some(1)), 1}})
+	}
+	require.Equal(t, run(choices()), run(choices()))
+
+	ys := run(Stagger(time.Millisecond, Seq(1, 2)))
+	require.Equal(t, []interface{}{1, 2}, ys)
+}
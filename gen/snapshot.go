@@ -0,0 +1,207 @@
+package gen
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Snapshotter is implemented by generators whose state can be serialized, so
+// that they can be resumed later (possibly in another process) by Restore.
+// Snapshot fails if a generator it is made of cannot be serialized: only Some,
+// Const, Seq, Concat, Cons, Limit, Repeat and RangeI64 can, while generators
+// driven by funcs, channels, goroutines or timers cannot.
+//
+// Values of Some and Const are restored with their type if it is a predeclared
+// boolean, numeric or string type, other values go through encoding/json and
+// are restored as it decodes them into an interface{}.
+type Snapshotter interface {
+	Snapshot() ([]byte, error)
+}
+
+// Restore resumes a generator from data returned by Snapshot.
+func Restore(data []byte) (Generator, error) {
+	var n *snapshotNode
+	if err := json.Unmarshal(data, &n); err != nil {
+		return nil, fmt.Errorf("gen: restore: %w", err)
+	}
+	g, err := n.decode()
+	if err != nil {
+		return nil, fmt.Errorf("gen: restore: %w", err)
+	}
+	return g, nil
+}
+
+func snapshot(g Generator) ([]byte, error) {
+	n, err := encodeSnapshot(g)
+	if err != nil {
+		return nil, fmt.Errorf("gen: snapshot: %w", err)
+	}
+	return json.Marshal(n)
+}
+
+func (g some) Snapshot() ([]byte, error) { return snapshot(g) }
+
+func (g constant) Snapshot() ([]byte, error) { return snapshot(g) }
+
+func (g cons) Snapshot() ([]byte, error) { return snapshot(g) }
+
+func (gs seq) Snapshot() ([]byte, error) { return snapshot(gs) }
+
+func (g limit) Snapshot() ([]byte, error) { return snapshot(g) }
+
+func (g repeat) Snapshot() ([]byte, error) { return snapshot(g) }
+
+func (g rangeI64) Snapshot() ([]byte, error) { return snapshot(g) }
+
+// snapshotNode is the serialized form of a generator, a nil node stands for a
+// nil generator.
+type snapshotNode struct {
+	Type  string          `json:"type"`
+	Ints  []int64         `json:"ints,omitempty"`
+	Value *snapshotValue  `json:"value,omitempty"`
+	Gens  []*snapshotNode `json:"gens,omitempty"`
+}
+
+func encodeSnapshot(g Generator) (*snapshotNode, error) {
+	switch g := g.(type) {
+	case nil:
+		return nil, nil
+	case some:
+		v, err := encodeValue(g.val)
+		return &snapshotNode{Type: "some", Value: v}, err
+	case constant:
+		v, err := encodeValue(g.val)
+		return &snapshotNode{Type: "const", Value: v}, err
+	case cons:
+		return encodeGens("cons", nil, g.head, g.tail)
+	case seq:
+		return encodeGens("seq", nil, g...)
+	case limit:
+		return encodeGens("limit", []int64{int64(g.remaining)}, g.inner)
+	case repeat:
+		return encodeGens("repeat", nil, g.orig, g.iter)
+	case rangeI64:
+		return &snapshotNode{Type: "range", Ints: []int64{g.start, g.end, g.step}}, nil
+	default:
+		return nil, fmt.Errorf("%T is not serializable", g)
+	}
+}
+
+func encodeGens(typ string, ints []int64, gs ...Generator) (*snapshotNode, error) {
+	n := &snapshotNode{Type: typ, Ints: ints, Gens: make([]*snapshotNode, len(gs))}
+	for i, g := range gs {
+		x, err := encodeSnapshot(g)
+		if err != nil {
+			return nil, err
+		}
+		n.Gens[i] = x
+	}
+	return n, nil
+}
+
+// snapshotArity is the number of ints and generators of each node type.
+var snapshotArity = map[string][2]int{
+	"some": {0, 0}, "const": {0, 0}, "cons": {0, 2}, "limit": {1, 1}, "repeat": {0, 2}, "range": {3, 0},
+}
+
+func (n *snapshotNode) decode() (Generator, error) {
+	if n == nil {
+		return nil, nil
+	}
+	gs := make([]Generator, len(n.Gens))
+	for i, x := range n.Gens {
+		g, err := x.decode()
+		if err != nil {
+			return nil, err
+		}
+		gs[i] = g
+	}
+	if a, ok := snapshotArity[n.Type]; ok && (len(n.Ints) != a[0] || len(gs) != a[1]) {
+		return nil, fmt.Errorf("malformed %s", n.Type)
+	}
+	switch n.Type {
+	case "some", "const":
+		if n.Value == nil {
+			return nil, fmt.Errorf("malformed %s", n.Type)
+		}
+		v, err := n.Value.decode()
+		if err != nil {
+			return nil, err
+		}
+		if n.Type == "some" {
+			return some{v}, nil
+		}
+		return constant{v}, nil
+	case "cons":
+		return cons{gs[0], gs[1]}, nil
+	case "seq":
+		return seq(gs), nil
+	case "limit":
+		return limit{gs[0], int(n.Ints[0])}, nil
+	case "repeat":
+		return repeat{gs[0], gs[1]}, nil
+	case "range":
+		return rangeI64{n.Ints[0], n.Ints[1], n.Ints[2]}, nil
+	default:
+		return nil, fmt.Errorf("unknown generator type %q", n.Type)
+	}
+}
+
+type snapshotValue struct {
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+var snapshotKinds = map[string]reflect.Type{}
+
+func init() {
+	for _, x := range []interface{}{
+		false, "",
+		int(0), int8(0), int16(0), int32(0), int64(0),
+		uint(0), uint8(0), uint16(0), uint32(0), uint64(0), uintptr(0),
+		float32(0), float64(0),
+	} {
+		t := reflect.TypeOf(x)
+		snapshotKinds[t.Name()] = t
+	}
+}
+
+func encodeValue(x interface{}) (*snapshotValue, error) {
+	kind := "json"
+	switch {
+	case x == nil:
+		return &snapshotValue{Kind: "nil"}, nil
+	case x == Pending:
+		return &snapshotValue{Kind: "pending"}, nil
+	case snapshotKinds[reflect.TypeOf(x).String()] == reflect.TypeOf(x):
+		kind = reflect.TypeOf(x).Name()
+	}
+	data, err := json.Marshal(x)
+	if err != nil {
+		return nil, err
+	}
+	return &snapshotValue{kind, data}, nil
+}
+
+func (v *snapshotValue) decode() (interface{}, error) {
+	switch v.Kind {
+	case "nil":
+		return nil, nil
+	case "pending":
+		return Pending, nil
+	case "json":
+		var x interface{}
+		err := json.Unmarshal(v.Data, &x)
+		return x, err
+	}
+	t, ok := snapshotKinds[v.Kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown value kind %q", v.Kind)
+	}
+	p := reflect.New(t)
+	if err := json.Unmarshal(v.Data, p.Interface()); err != nil {
+		return nil, err
+	}
+	return p.Elem().Interface(), nil
+}
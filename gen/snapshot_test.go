@@ -0,0 +1,62 @@
+package gen
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	ctx := context.Background()
+	for _, tt := range []struct {
+		name string
+		g    Generator
+		skip int
+	}{
+		{"Range", RangeI64(1, 20, 3), 2},
+		{"Range", RangeI64(1<<40, 0, -1<<38), 1},
+		{"Values", Seq(1, int64(2), uint8(3), 4.5, float32(5), "a", true, Pending), 3},
+		{"Limit", Limit(5, RangeI64()), 1},
+		{"Repeat", Limit(7, Repeat(Seq(1, 2, 3))), 4},
+		{"Cons", Cons(Seq(1), Limit(2, Const("x"))), 0},
+		{"Concat", Concat(Seq(1), RangeI64(0, 2)), 1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			g := tt.g
+			for i := 0; i < tt.skip; i++ {
+				_, g = g.Next(ctx)
+			}
+			data, err := g.(Snapshotter).Snapshot()
+			require.NoError(t, err)
+			rg, err := Restore(data)
+			require.NoError(t, err)
+			require.Equal(t, exhaust(g), exhaust(rg))
+		})
+	}
+
+	t.Run("Map", func(t *testing.T) {
+		data, err := Seq(map[string]interface{}{"a": 1}).(Snapshotter).Snapshot()
+		require.NoError(t, err)
+		g, err := Restore(data)
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{map[string]interface{}{"a": 1.0}}, exhaust(g))
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		_, err := Limit(3, Some(func() interface{} { return 1 })).(Snapshotter).Snapshot()
+		require.Error(t, err)
+		_, err = Concat(Seq(1), Map(identity, Seq(2))).(Snapshotter).Snapshot()
+		require.Error(t, err)
+	})
+
+	t.Run("Malformed", func(t *testing.T) {
+		for _, data := range []string{
+			``, `{`, `{"type":"nope"}`, `{"type":"limit","ints":[1]}`,
+			`{"type":"some"}`, `{"type":"some","value":{"kind":"int","data":"x"}}`,
+		} {
+			_, err := Restore([]byte(data))
+			require.Error(t, err, data)
+		}
+	})
+}
@@ -0,0 +1,433 @@
+package gen
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// FromSlice yields the elements of xs verbatim. Unlike Seq, nil elements are
+// kept and elements that are generators (or funcs, channels) are emitted as
+// values rather than being driven.
+func FromSlice(xs []interface{}) Generator {
+	if len(xs) == 0 {
+		return nil
+	}
+	return fromSlice(xs)
+}
+
+type fromSlice []interface{}
+
+func (g fromSlice) Update(ctx context.Context) Generator { return g }
+
+func (g fromSlice) Next(ctx context.Context) (interface{}, Generator) {
+	if len(g) == 0 {
+		return StopIteration, nil
+	}
+	return g[0], FromSlice(g[1:])
+}
+
+// FromChannel yields the values received from c, like Some(c). Next blocks
+// until a value is received or ctx is done. Once c is closed and drained, Next
+// returns StopIteration, even if ctx is done. If ctx is done and no value is
+// ready, Next returns Pending along with the same generator, which resumes
+// receiving from c when called again; values buffered in c are always received
+// before Pending is returned. A nil c gives a nil generator.
+func FromChannel(c <-chan interface{}) Generator {
+	if c == nil {
+		return nil
+	}
+	return ch(c)
+}
+
+func Iterate(x interface{}, f func(x interface{}) interface{}) Generator {
+	return IterateCtx(x, func(_ context.Context, x interface{}) interface{} { return f(x) })
+}
+
+func IterateCtx(x interface{}, f func(ctx context.Context, x interface{}) interface{}) Generator {
+	return iterate{x, f, true}
+}
+
+type iterate struct {
+	x     interface{}
+	f     func(context.Context, interface{}) interface{}
+	first bool
+}
+
+func (g iterate) Update(ctx context.Context) Generator { return g }
+
+func (g iterate) Next(ctx context.Context) (interface{}, Generator) {
+	if !g.first {
+		g.x = g.f(ctx, g.x)
+	}
+	return g.x, iterate{g.x, g.f, false}
+}
+
+func Unfold(seed interface{}, f func(x interface{}) (value interface{}, next interface{}, ok bool)) Generator {
+	return unfold{seed, f}
+}
+
+type unfold struct {
+	seed interface{}
+	f    func(interface{}) (interface{}, interface{}, bool)
+}
+
+func (g unfold) Update(ctx context.Context) Generator { return g }
+
+func (g unfold) Next(ctx context.Context) (interface{}, Generator) {
+	x, next, ok := g.f(g.seed)
+	if !ok {
+		return StopIteration, nil
+	}
+	return x, unfold{next, g.f}
+}
+
+// RangeTime yields start, start+step, ... up to an optional end bound (args[0])
+// with the same step semantics as RangeI64. Without a bound it stops once the
+// next value would fall outside the range time.Time can represent.
+func RangeTime(start time.Time, step time.Duration, args ...time.Time) Generator {
+	g := rangeTime{start: start, step: step}
+	if len(args) == 1 {
+		g.end, g.bounded = args[0], true
+	} else if len(args) > 1 {
+		return nil
+	}
+	if !g.hasNext() {
+		return nil
+	}
+	return g
+}
+
+type rangeTime struct {
+	start   time.Time
+	end     time.Time
+	bounded bool
+	step    time.Duration
+}
+
+func (g rangeTime) hasNext() bool {
+	return !g.bounded || (g.step >= 0 && g.start.Before(g.end)) || (g.step <= 0 && g.start.After(g.end))
+}
+
+func (g rangeTime) Update(ctx context.Context) Generator {
+	if !g.hasNext() {
+		return nil
+	}
+	return g
+}
+
+func (g rangeTime) Next(ctx context.Context) (interface{}, Generator) {
+	if !g.hasNext() {
+		return StopIteration, nil
+	}
+	ng := g
+	ng.start = g.start.Add(g.step)
+	if (g.step > 0 && !ng.start.After(g.start)) || (g.step < 0 && !ng.start.Before(g.start)) || !ng.hasNext() {
+		return g.start, nil
+	}
+	return g.start, ng
+}
+
+// Linspace yields n evenly spaced values from start to stop inclusive.
+func Linspace(start float64, stop float64, n int) Generator {
+	if n <= 0 {
+		return nil
+	}
+	return linspace{start, stop, n, 0}
+}
+
+type linspace struct {
+	start float64
+	stop  float64
+	n     int
+	i     int
+}
+
+func (g linspace) Update(ctx context.Context) Generator { return g }
+
+func (g linspace) Next(ctx context.Context) (interface{}, Generator) {
+	if g.i >= g.n {
+		return StopIteration, nil
+	}
+	x := g.start
+	if g.i == g.n-1 && g.n > 1 {
+		x = g.stop
+	} else if g.i > 0 {
+		x = g.start + float64(g.i)*(g.stop-g.start)/float64(g.n-1)
+	}
+	if g.i++; g.i >= g.n {
+		return x, nil
+	}
+	return x, g
+}
+
+// MaxPermutationsLen is the largest input Permutations accepts: 20! is the
+// largest factorial an int64 (like the result of Count) can hold.
+const MaxPermutationsLen = 20
+
+// Permutations yields each permutation of xs as a new []interface{}, in
+// lexicographic order of positions, so equal values are not merged. Only the
+// current permutation is kept, but there are len(xs)! of them. It returns nil
+// if len(xs) > MaxPermutationsLen.
+func Permutations(xs []interface{}) Generator {
+	if len(xs) > MaxPermutationsLen {
+		return nil
+	}
+	idx := make([]int, len(xs))
+	for i := range idx {
+		idx[i] = i
+	}
+	return permutations{append([]interface{}(nil), xs...), idx}
+}
+
+type permutations struct {
+	xs  []interface{}
+	idx []int
+}
+
+func (g permutations) Update(ctx context.Context) Generator { return g }
+
+func (g permutations) Next(ctx context.Context) (interface{}, Generator) {
+	out := make([]interface{}, len(g.idx))
+	for i, j := range g.idx {
+		out[i] = g.xs[j]
+	}
+	idx := nextPermutation(g.idx)
+	if idx == nil {
+		return out, nil
+	}
+	return out, permutations{g.xs, idx}
+}
+
+// nextPermutation returns the permutation following p in lexicographic order,
+// or nil if p is the last one.
+func nextPermutation(p []int) []int {
+	i := len(p) - 2
+	for i >= 0 && p[i] >= p[i+1] {
+		i--
+	}
+	if i < 0 {
+		return nil
+	}
+	p = append([]int(nil), p...)
+	j := len(p) - 1
+	for p[j] <= p[i] {
+		j--
+	}
+	p[i], p[j] = p[j], p[i]
+	for l, r := i+1, len(p)-1; l < r; l, r = l+1, r-1 {
+		p[l], p[r] = p[r], p[l]
+	}
+	return p
+}
+
+// Combinations yields each k-element subset of xs as a new []interface{}, in
+// lexicographic order of positions. It returns nil if k < 0 or k > len(xs).
+func Combinations(k int, xs []interface{}) Generator {
+	if k < 0 || k > len(xs) {
+		return nil
+	}
+	idx := make([]int, k)
+	for i := range idx {
+		idx[i] = i
+	}
+	return combinations{append([]interface{}(nil), xs...), idx}
+}
+
+type combinations struct {
+	xs  []interface{}
+	idx []int
+}
+
+func (g combinations) Update(ctx context.Context) Generator { return g }
+
+func (g combinations) Next(ctx context.Context) (interface{}, Generator) {
+	out := make([]interface{}, len(g.idx))
+	for i, j := range g.idx {
+		out[i] = g.xs[j]
+	}
+	n, k := len(g.xs), len(g.idx)
+	i := k - 1
+	for i >= 0 && g.idx[i] == n-k+i {
+		i--
+	}
+	if i < 0 {
+		return out, nil
+	}
+	idx := append([]int(nil), g.idx...)
+	idx[i]++
+	for j := i + 1; j < k; j++ {
+		idx[j] = idx[j-1] + 1
+	}
+	return out, combinations{g.xs, idx}
+}
+
+// CartesianProduct yields each tuple of the cartesian product of xss as a new
+// []interface{}, the last element varies fastest. It returns nil if any of xss
+// is empty.
+func CartesianProduct(xss ...[]interface{}) Generator {
+	for _, xs := range xss {
+		if len(xs) == 0 {
+			return nil
+		}
+	}
+	return product{append([][]interface{}(nil), xss...), make([]int, len(xss))}
+}
+
+type product struct {
+	xss [][]interface{}
+	idx []int
+}
+
+func (g product) Update(ctx context.Context) Generator { return g }
+
+func (g product) Next(ctx context.Context) (interface{}, Generator) {
+	out := make([]interface{}, len(g.idx))
+	for i, j := range g.idx {
+		out[i] = g.xss[i][j]
+	}
+	idx := append([]int(nil), g.idx...)
+	for i := len(idx) - 1; i >= 0; i-- {
+		if idx[i]++; idx[i] < len(g.xss[i]) {
+			return out, product{g.xss, idx}
+		}
+		idx[i] = 0
+	}
+	return out, nil
+}
+
+// ScanError is yielded by FromLines and FromJSONArray when reading or decoding
+// fails, before stopping.
+type ScanError struct{ Err error }
+
+func (e *ScanError) Error() string { return "scan: " + e.Err.Error() }
+
+func (e *ScanError) Unwrap() error { return e.Err }
+
+// FromLines yields each line read from r as a string, without its line ending.
+// Lines longer than bufio.MaxScanTokenSize fail with a *ScanError, use
+// FromLinesSize to change the limit. Reading r blocks regardless of ctx. Like
+// Prefetch, the returned generator is stateful: lines are consumed from r as
+// Next is called, so replaying an earlier state continues where the last call
+// left off.
+func FromLines(r io.Reader) Generator { return FromLinesSize(r, bufio.MaxScanTokenSize) }
+
+// FromLinesSize is like FromLines but allows lines of up to max bytes.
+func FromLinesSize(r io.Reader, max int) Generator {
+	if r == nil || max <= 0 {
+		return nil
+	}
+	s := bufio.NewScanner(r)
+	size := 4096
+	if max < size {
+		size = max
+	}
+	s.Buffer(make([]byte, size), max)
+	return &lines{s: s}
+}
+
+type lines struct {
+	mu   sync.Mutex
+	s    *bufio.Scanner
+	done bool
+}
+
+func (g *lines) Update(ctx context.Context) Generator {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.done {
+		return nil
+	}
+	return g
+}
+
+func (g *lines) Next(ctx context.Context) (interface{}, Generator) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.done {
+		return StopIteration, nil
+	}
+	if g.s.Scan() {
+		return g.s.Text(), g
+	}
+	g.done = true
+	if err := g.s.Err(); err != nil {
+		return &ScanError{err}, nil
+	}
+	return StopIteration, nil
+}
+
+// FromJSONArray yields each element of the JSON array read from r as a
+// json.RawMessage. r is decoded incrementally, so the array doesn't need to fit
+// in memory; anything following it is ignored. Invalid input yields a
+// *ScanError. Like FromLines, the returned generator is stateful and reading r
+// blocks regardless of ctx.
+func FromJSONArray(r io.Reader) Generator { return FromJSONArrayInto(r, nil) }
+
+// FromJSONArrayInto is like FromJSONArray but decodes each element into a new
+// value returned by newValue, which must be a pointer, and yields that pointer.
+func FromJSONArrayInto(r io.Reader, newValue func() interface{}) Generator {
+	if r == nil {
+		return nil
+	}
+	return &jsonArray{dec: json.NewDecoder(r), newValue: newValue}
+}
+
+type jsonArray struct {
+	mu       sync.Mutex
+	dec      *json.Decoder
+	newValue func() interface{}
+	started  bool
+	done     bool
+}
+
+func (g *jsonArray) Update(ctx context.Context) Generator {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.done {
+		return nil
+	}
+	return g
+}
+
+func (g *jsonArray) Next(ctx context.Context) (interface{}, Generator) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.done {
+		return StopIteration, nil
+	}
+	if !g.started {
+		tok, err := g.dec.Token()
+		if err == nil && tok != json.Delim('[') {
+			err = fmt.Errorf("expected a JSON array, got %v", tok)
+		}
+		if err != nil {
+			g.done = true
+			return &ScanError{err}, nil
+		}
+		g.started = true
+	}
+	if !g.dec.More() {
+		g.done = true
+		if _, err := g.dec.Token(); err != nil {
+			return &ScanError{err}, nil
+		}
+		return StopIteration, nil
+	}
+	var x interface{} = new(json.RawMessage)
+	if g.newValue != nil {
+		x = g.newValue()
+	}
+	if err := g.dec.Decode(x); err != nil {
+		g.done = true
+		return &ScanError{err}, nil
+	}
+	if g.newValue == nil {
+		return *x.(*json.RawMessage), g
+	}
+	return x, g
+}
@@ -0,0 +1,327 @@
+package gen
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromSlice(t *testing.T) {
+	g := Seq(1, 2)
+	for _, tt := range []struct {
+		name string
+		g    Generator
+		r    []interface{}
+	}{
+		{"Nil", FromSlice(nil), nil},
+		{"Empty", FromSlice([]interface{}{}), nil},
+		{"Values", FromSlice([]interface{}{1, "a"}), []interface{}{1, "a"}},
+		{"WithNil", FromSlice([]interface{}{1, nil, "a"}), []interface{}{1, nil, "a"}},
+		{"Generator", FromSlice([]interface{}{g, 3}), []interface{}{g, 3}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.r, exhaust(tt.g))
+		})
+	}
+}
+
+func TestFromChannel(t *testing.T) {
+	src := func(xs ...interface{}) <-chan interface{} {
+		ch := make(chan interface{}, len(xs))
+		for _, x := range xs {
+			ch <- x
+		}
+		close(ch)
+		return ch
+	}
+
+	require.Nil(t, FromChannel(nil))
+	require.Nil(t, exhaust(FromChannel(src())))
+	require.Equal(t, []interface{}{1, nil, "a"}, exhaust(FromChannel(src(1, nil, "a"))))
+
+	t.Run("Cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		ch := make(chan interface{}, 2)
+		g := FromChannel(ch)
+		x, g := g.Next(ctx)
+		require.True(t, IsPending(x))
+		require.NotNil(t, g)
+		ch <- 1
+		ch <- 2
+		close(ch)
+		for _, v := range []interface{}{1, 2} {
+			x, g = g.Next(ctx)
+			require.Equal(t, v, x)
+		}
+		x, g = g.Next(ctx)
+		require.True(t, IsStopIteration(x))
+		require.Nil(t, g)
+	})
+
+	t.Run("Blocking", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		ch := make(chan interface{})
+		go func() { ch <- 1 }()
+		x, g := FromChannel(ch).Next(context.Background())
+		require.Equal(t, 1, x)
+		x, g = g.Next(ctx)
+		require.True(t, IsPending(x))
+		require.NotNil(t, g)
+	})
+}
+
+func TestIterate(t *testing.T) {
+	double := func(x interface{}) interface{} { return x.(int) * 2 }
+	calls := 0
+	inc := func(ctx context.Context, x interface{}) interface{} {
+		calls++
+		require.NotNil(t, ctx)
+		return x.(int) + 1
+	}
+
+	require.Equal(t, []interface{}{1, 2, 4, 8}, exhaust(Limit(4, Iterate(1, double))))
+	require.Equal(t, []interface{}{0, 1, 2}, exhaust(Limit(3, IterateCtx(0, inc))))
+	require.Equal(t, 2, calls)
+}
+
+func ExampleIterate() {
+	lcg := Iterate(uint32(1), func(x interface{}) interface{} {
+		return x.(uint32)*1103515245 + 12345
+	})
+	fmt.Println(ToSlice(context.Background(), Limit(4, lcg)))
+	// Output: [1 1103527590 2524885223 662824084]
+}
+
+func ExampleUnfold() {
+	fib := Unfold([2]int{0, 1}, func(x interface{}) (interface{}, interface{}, bool) {
+		s := x.([2]int)
+		return s[0], [2]int{s[1], s[0] + s[1]}, s[0] < 50
+	})
+	fmt.Println(ToSlice(context.Background(), fib))
+	// Output: [0 1 1 2 3 5 8 13 21 34]
+}
+
+func TestUnfold(t *testing.T) {
+	ctx := context.Background()
+	pages := func(x interface{}) (interface{}, interface{}, bool) {
+		cursor := x.(int)
+		if cursor >= 3 {
+			return nil, nil, false
+		}
+		return "page" + strconv.Itoa(cursor), cursor + 1, true
+	}
+
+	require.Equal(t, []interface{}{"page0", "page1", "page2"}, exhaust(Unfold(0, pages)))
+	require.Nil(t, exhaust(Unfold(3, pages)))
+
+	x, g := Unfold(3, pages).Next(ctx)
+	require.True(t, IsStopIteration(x))
+	require.Nil(t, g)
+}
+
+func TestRangeTime(t *testing.T) {
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	ts := func(ds ...time.Duration) []interface{} {
+		xs := make([]interface{}, len(ds))
+		for i, d := range ds {
+			xs[i] = t0.Add(d)
+		}
+		return xs
+	}
+	max := time.Unix(math.MaxInt64-62135596800, 999999999)
+
+	for i, tt := range []struct {
+		g Generator
+		r []interface{}
+	}{
+		{Limit(3, RangeTime(t0, time.Hour)), ts(0, time.Hour, 2*time.Hour)},
+		{RangeTime(t0, time.Hour, t0.Add(150*time.Minute)), ts(0, time.Hour, 2*time.Hour)},
+		{RangeTime(t0, -time.Hour, t0.Add(-2*time.Hour)), ts(0, -time.Hour)},
+		{RangeTime(t0, time.Hour, t0.Add(-time.Hour)), nil},
+		{RangeTime(t0, time.Hour, t0, t0), nil},
+		{Limit(3, RangeTime(t0, 0)), ts(0, 0, 0)},
+		{Limit(3, RangeTime(max.Add(-time.Second), time.Second)), []interface{}{max.Add(-time.Second), max}},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			require.Equal(t, tt.r, exhaust(tt.g))
+		})
+	}
+}
+
+func TestLinspace(t *testing.T) {
+	f64s := func(ns ...float64) []interface{} {
+		xs := make([]interface{}, len(ns))
+		for i, n := range ns {
+			xs[i] = n
+		}
+		return xs
+	}
+	for i, tt := range []struct {
+		g Generator
+		r []interface{}
+	}{
+		{Linspace(0, 1, 0), nil},
+		{Linspace(0, 1, -1), nil},
+		{Linspace(3, 1, 1), f64s(3)},
+		{Linspace(0, 1, 2), f64s(0, 1)},
+		{Linspace(0, 1, 5), f64s(0, .25, .5, .75, 1)},
+		{Linspace(1, -1, 3), f64s(1, 0, -1)},
+		{Linspace(2, 2, 3), f64s(2, 2, 2)},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			require.Equal(t, tt.r, exhaust(tt.g))
+		})
+	}
+
+	xs := exhaust(Linspace(0, 1, 11))
+	require.Len(t, xs, 11)
+	require.Equal(t, .3, xs[3])
+	require.Equal(t, 1.0, xs[10])
+}
+
+func TestPermutations(t *testing.T) {
+	xs := func(xs ...interface{}) []interface{} { return xs }
+
+	for _, tt := range []struct {
+		name string
+		g    Generator
+		r    []interface{}
+	}{
+		{"Empty", Permutations(nil), xs([]interface{}{})},
+		{"One", Permutations(xs(1)), xs(xs(1))},
+		{"Two", Permutations(xs(1, 2)), xs(xs(1, 2), xs(2, 1))},
+		{"Three", Permutations(xs(1, 2, 3)), xs(xs(1, 2, 3), xs(1, 3, 2), xs(2, 1, 3), xs(2, 3, 1), xs(3, 1, 2), xs(3, 2, 1))},
+		{"Equal", Permutations(xs("a", "a")), xs(xs("a", "a"), xs("a", "a"))},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.r, exhaust(tt.g))
+		})
+	}
+
+	require.Equal(t, int64(5040), Count(context.Background(), Permutations(xs(1, 2, 3, 4, 5, 6, 7))))
+	require.Len(t, ToSliceN(context.Background(), 3, Permutations(make([]interface{}, MaxPermutationsLen))), 3)
+	require.Nil(t, Permutations(make([]interface{}, MaxPermutationsLen+1)))
+}
+
+func TestCombinations(t *testing.T) {
+	xs := func(xs ...interface{}) []interface{} { return xs }
+	empty := []interface{}{}
+
+	for _, tt := range []struct {
+		name string
+		g    Generator
+		r    []interface{}
+	}{
+		{"Negative", Combinations(-1, xs(1)), nil},
+		{"TooMany", Combinations(2, xs(1)), nil},
+		{"Zero", Combinations(0, xs(1, 2)), xs(empty)},
+		{"Zero", Combinations(0, nil), xs(empty)},
+		{"One", Combinations(1, xs(1, 2, 3)), xs(xs(1), xs(2), xs(3))},
+		{"Two", Combinations(2, xs(1, 2, 3, 4)), xs(xs(1, 2), xs(1, 3), xs(1, 4), xs(2, 3), xs(2, 4), xs(3, 4))},
+		{"All", Combinations(3, xs(1, 2, 3)), xs(xs(1, 2, 3))},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.r, exhaust(tt.g))
+		})
+	}
+
+	require.Equal(t, int64(252), Count(context.Background(), Combinations(5, make([]interface{}, 10))))
+}
+
+func TestCartesianProduct(t *testing.T) {
+	xs := func(xs ...interface{}) []interface{} { return xs }
+
+	for _, tt := range []struct {
+		name string
+		g    Generator
+		r    []interface{}
+	}{
+		{"None", CartesianProduct(), xs([]interface{}{})},
+		{"Empty", CartesianProduct(xs(1), nil), nil},
+		{"Single", CartesianProduct(xs(1, 2)), xs(xs(1), xs(2))},
+		{"Product", CartesianProduct(xs(1, 2), xs("a", "b", "c")), xs(xs(1, "a"), xs(1, "b"), xs(1, "c"), xs(2, "a"), xs(2, "b"), xs(2, "c"))},
+		{"Product", CartesianProduct(xs(1), xs(2), xs(3, 4)), xs(xs(1, 2, 3), xs(1, 2, 4))},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.r, exhaust(tt.g))
+		})
+	}
+
+	require.Equal(t, int64(60), Count(context.Background(), CartesianProduct(make([]interface{}, 3), make([]interface{}, 4), make([]interface{}, 5))))
+}
+
+func TestFromLines(t *testing.T) {
+	require.Nil(t, FromLines(nil))
+	require.Nil(t, FromLinesSize(strings.NewReader("a"), 0))
+	require.Nil(t, exhaust(FromLines(strings.NewReader(""))))
+	require.Equal(t, []interface{}{"a", "", "b", "c"}, exhaust(FromLines(strings.NewReader("a\n\nb\r\nc"))))
+
+	t.Run("TooLong", func(t *testing.T) {
+		xs := exhaust(FromLinesSize(strings.NewReader("abc\nabcdef\nabc\n"), 5))
+		require.Len(t, xs, 2)
+		require.Equal(t, "abc", xs[0])
+		require.IsType(t, &ScanError{}, xs[1])
+		require.True(t, errors.Is(xs[1].(error), bufio.ErrTooLong))
+		require.False(t, IsStopIteration(xs[1]))
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		oops := errors.New("oops")
+		r := io.MultiReader(strings.NewReader("a\nb\n"), iotest.ErrReader(oops))
+		g := FromLines(r)
+		xs := exhaust(g)
+		require.Equal(t, []interface{}{"a", "b", &ScanError{oops}}, xs)
+		x, g := g.Next(context.Background())
+		require.True(t, IsStopIteration(x))
+		require.Nil(t, g)
+	})
+}
+
+func TestFromJSONArray(t *testing.T) {
+	raw := func(ss ...string) []interface{} {
+		xs := make([]interface{}, len(ss))
+		for i, s := range ss {
+			xs[i] = json.RawMessage(s)
+		}
+		return xs
+	}
+
+	require.Nil(t, FromJSONArray(nil))
+	require.Nil(t, exhaust(FromJSONArray(strings.NewReader("[]"))))
+	require.Equal(t, raw(`1`, `"a"`, `{"b": [2]}`, `null`), exhaust(FromJSONArray(strings.NewReader(`[1, "a", {"b": [2]}, null] "rest"`))))
+
+	t.Run("Into", func(t *testing.T) {
+		type point struct{ X, Y int }
+		g := FromJSONArrayInto(strings.NewReader(`[{"X": 1}, {"Y": 2}]`), func() interface{} { return new(point) })
+		require.Equal(t, []interface{}{&point{X: 1}, &point{Y: 2}}, exhaust(g))
+	})
+
+	t.Run("Incremental", func(t *testing.T) {
+		r := io.MultiReader(strings.NewReader(`[1, 2, `), iotest.ErrReader(errors.New("oops")))
+		xs := exhaust(FromJSONArray(r))
+		require.Len(t, xs, 3)
+		require.Equal(t, raw(`1`, `2`), xs[:2])
+		require.IsType(t, &ScanError{}, xs[2])
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, s := range []string{``, `{}`, `1`, `[1,`, `[1 2]`, `[}`} {
+			xs := exhaust(FromJSONArray(strings.NewReader(s)))
+			require.NotEmpty(t, xs, s)
+			require.IsType(t, &ScanError{}, xs[len(xs)-1], s)
+		}
+	})
+}
@@ -0,0 +1,219 @@
+package gen
+
+import (
+	"context"
+	"sync"
+)
+
+// Partition splits g into values for which pred is true and the rest. Both
+// generators share a single pass over g: values pulled by one side but routed
+// to the other are buffered until the other side consumes them, so draining
+// only one side keeps every value of the other in memory. Like Prefetch, the
+// returned generators are stateful.
+func Partition(pred func(x interface{}) bool, g Generator) (yes, no Generator) {
+	if g == nil {
+		return nil, nil
+	}
+	s := newTeeSource(2, 0, g, func(x interface{}) int {
+		if pred(x) {
+			return 0
+		}
+		return 1
+	})
+	return &teeBranch{s, 0}, &teeBranch{s, 1}
+}
+
+// Tee returns n generators that each yield every value of g, pulling from g
+// only once. Values pulled by one branch are buffered for the others until they
+// consume them, so a branch that falls behind (or is abandoned) makes the buffer
+// grow without bound; use TeeBounded to cap it. The returned generators are
+// stateful and safe to consume from different goroutines.
+func Tee(n int, g Generator) []Generator { return TeeBounded(n, 0, g) }
+
+// TeeBounded is like Tee but lets no branch get more than cap values ahead of
+// the slowest one: a branch that would do so blocks in Next until the others
+// catch up or its ctx is done, in which case it yields Pending. Branches must
+// therefore be consumed concurrently. A cap <= 0 means unbounded.
+func TeeBounded(n int, cap int, g Generator) []Generator {
+	if g == nil || n <= 0 {
+		return nil
+	}
+	s := newTeeSource(n, cap, g, nil)
+	gs := make([]Generator, n)
+	for i := range gs {
+		gs[i] = &teeBranch{s, i}
+	}
+	return gs
+}
+
+// teeSource pulls values from inner on behalf of its branches and queues them
+// per branch. route picks the branch a value goes to, or all branches if it
+// is nil.
+type teeSource struct {
+	mu     sync.Mutex
+	inner  Generator
+	route  func(interface{}) int
+	queues [][]interface{}
+	cap    int
+	moved  chan struct{}
+}
+
+func newTeeSource(n int, cap int, g Generator, route func(interface{}) int) *teeSource {
+	return &teeSource{inner: g, route: route, queues: make([][]interface{}, n), cap: cap, moved: make(chan struct{})}
+}
+
+// full reports whether pulling a value for branch id would exceed the cap of
+// another branch's queue.
+func (s *teeSource) full(id int) bool {
+	if s.cap <= 0 {
+		return false
+	}
+	for i, q := range s.queues {
+		if i != id && len(q) >= s.cap {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *teeSource) pop(id int) interface{} {
+	q := s.queues[id]
+	x := q[0]
+	q[0] = nil
+	s.queues[id] = q[1:]
+	if s.cap > 0 {
+		close(s.moved)
+		s.moved = make(chan struct{})
+	}
+	return x
+}
+
+type teeBranch struct {
+	src *teeSource
+	id  int
+}
+
+func (g *teeBranch) Update(ctx context.Context) Generator {
+	s := g.src
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.inner != nil {
+		s.inner = s.inner.Update(ctx)
+	}
+	if s.inner == nil && len(s.queues[g.id]) == 0 {
+		return nil
+	}
+	return g
+}
+
+func (g *teeBranch) Next(ctx context.Context) (interface{}, Generator) {
+	s := g.src
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		if len(s.queues[g.id]) > 0 {
+			return s.pop(g.id), g
+		}
+		if s.inner == nil {
+			return StopIteration, nil
+		}
+		if s.full(g.id) {
+			moved := s.moved
+			s.mu.Unlock()
+			select {
+			case <-ctx.Done():
+				s.mu.Lock()
+				return Pending, g
+			case <-moved:
+			}
+			s.mu.Lock()
+			continue
+		}
+		x, ng := s.inner.Next(ctx)
+		if IsStopIteration(x) {
+			s.inner = nil
+			continue
+		}
+		s.inner = ng
+		if IsPending(x) {
+			return x, g
+		}
+		if s.route != nil {
+			if id := s.route(x); id != g.id {
+				s.queues[id] = append(s.queues[id], x)
+				continue
+			}
+			return x, g
+		}
+		for id := range s.queues {
+			if id != g.id {
+				s.queues[id] = append(s.queues[id], x)
+			}
+		}
+		return x, g
+	}
+}
+
+// Memoize records the values of g as they are pulled, so that replaying an
+// earlier state of the returned generator yields the same values again without
+// pulling g, and going beyond what has been recorded pulls g lazily. The record
+// is shared by all states derived from the returned generator and kept for its
+// whole lifetime, so memory grows with the number of values pulled.
+func Memoize(g Generator) Generator {
+	if g == nil {
+		return nil
+	}
+	return memo{&memoCache{inner: g}, 0}
+}
+
+type memoCache struct {
+	mu    sync.Mutex
+	inner Generator
+	xs    []interface{}
+}
+
+type memo struct {
+	c *memoCache
+	i int
+}
+
+// generator must be called with c.mu held.
+func (g memo) generator() Generator {
+	if g.i >= len(g.c.xs) && g.c.inner == nil {
+		return nil
+	}
+	return g
+}
+
+func (g memo) Update(ctx context.Context) Generator {
+	g.c.mu.Lock()
+	defer g.c.mu.Unlock()
+	if g.i >= len(g.c.xs) && g.c.inner != nil {
+		g.c.inner = g.c.inner.Update(ctx)
+	}
+	return g.generator()
+}
+
+func (g memo) Next(ctx context.Context) (interface{}, Generator) {
+	c := g.c
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for g.i >= len(c.xs) {
+		if c.inner == nil {
+			return StopIteration, nil
+		}
+		x, ng := c.inner.Next(ctx)
+		if IsStopIteration(x) {
+			c.inner = nil
+			continue
+		}
+		c.inner = ng
+		if IsPending(x) {
+			return x, g.generator()
+		}
+		c.xs = append(c.xs, x)
+	}
+	x := c.xs[g.i]
+	g.i++
+	return x, g.generator()
+}
@@ -0,0 +1,179 @@
+package gen
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartition(t *testing.T) {
+	even := func(x interface{}) bool { return x.(int)%2 == 0 }
+
+	yes, no := Partition(even, nil)
+	require.Nil(t, yes)
+	require.Nil(t, no)
+
+	yes, no = Partition(even, Seq(1, 2, 3, 4, 5))
+	require.Equal(t, []interface{}{2, 4}, exhaust(yes))
+	require.Equal(t, []interface{}{1, 3, 5}, exhaust(no))
+
+	yes, no = Partition(even, Seq(1, 2, 3, 4, 5))
+	require.Equal(t, []interface{}{1, 3, 5}, exhaust(no))
+	require.Equal(t, []interface{}{2, 4}, exhaust(yes))
+
+	t.Run("Interleaved", func(t *testing.T) {
+		ctx := context.Background()
+		yes, no := Partition(even, naturals())
+		for i := 0; i < 5; i++ {
+			var x, y interface{}
+			x, yes = yes.Next(ctx)
+			y, no = no.Next(ctx)
+			require.Equal(t, 2*i, x)
+			require.Equal(t, 2*i+1, y)
+		}
+		require.Empty(t, yes.(*teeBranch).src.queues[0])
+		require.Empty(t, yes.(*teeBranch).src.queues[1])
+	})
+
+	t.Run("SideEffects", func(t *testing.T) {
+		n := 0
+		yes, no := Partition(even, Limit(5, Some(func() interface{} {
+			n++
+			return n
+		})))
+		require.Equal(t, []interface{}{2, 4}, exhaust(yes))
+		require.Equal(t, []interface{}{1, 3, 5}, exhaust(no))
+		require.Equal(t, 5, n)
+	})
+
+	t.Run("Pending", func(t *testing.T) {
+		yes, no := Partition(even, Seq(1, Pending, 2))
+		require.Equal(t, []interface{}{Pending, 2}, exhaust(yes))
+		require.Equal(t, []interface{}{1}, exhaust(no))
+	})
+}
+
+func TestTee(t *testing.T) {
+	require.Nil(t, Tee(2, nil))
+	require.Nil(t, Tee(0, Seq(1)))
+
+	gs := Tee(3, Seq(1, 2, 3))
+	require.Len(t, gs, 3)
+	for _, g := range gs {
+		require.Equal(t, []interface{}{1, 2, 3}, exhaust(g))
+	}
+
+	t.Run("SideEffects", func(t *testing.T) {
+		n := 0
+		gs := Tee(2, Limit(3, Some(func() interface{} {
+			n++
+			return n
+		})))
+		require.Equal(t, []interface{}{1, 2, 3}, exhaust(gs[1]))
+		require.Equal(t, []interface{}{1, 2, 3}, exhaust(gs[0]))
+		require.Equal(t, 3, n)
+	})
+
+	t.Run("Free", func(t *testing.T) {
+		ctx := context.Background()
+		gs := Tee(2, naturals())
+		for i := 0; i < 5; i++ {
+			x, _ := gs[0].Next(ctx)
+			require.Equal(t, i, x)
+		}
+		s := gs[0].(*teeBranch).src
+		require.Len(t, s.queues[1], 5)
+		require.Equal(t, []interface{}{0, 1, 2, 3, 4}, ToSlice(ctx, Limit(5, gs[1])))
+		require.Empty(t, s.queues[0])
+		require.Empty(t, s.queues[1])
+	})
+
+	t.Run("Bounded", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		gs := TeeBounded(2, 2, naturals())
+		for i := 0; i < 2; i++ {
+			x, _ := gs[0].Next(ctx)
+			require.Equal(t, i, x)
+		}
+		x, _ := gs[0].Next(ctx)
+		require.True(t, IsPending(x))
+
+		done := make(chan []interface{})
+		go func() { done <- ToSlice(context.Background(), Limit(5, gs[1])) }()
+		require.Equal(t, []interface{}{2, 3, 4}, ToSlice(context.Background(), Limit(3, gs[0])))
+		require.Equal(t, []interface{}{0, 1, 2, 3, 4}, <-done)
+	})
+}
+
+func TestTeeBoundedConcurrent(t *testing.T) {
+	const n, cap, total = 4, 3, 200
+	gs := TeeBounded(n, cap, Limit(total, naturals()))
+	s := gs[0].(*teeBranch).src
+	max := 0
+	out := make(chan []interface{}, n)
+	for _, g := range gs {
+		go func(g Generator) {
+			var xs []interface{}
+			for x := range AsChannel(context.Background(), Tap(func(interface{}) {
+				s.mu.Lock()
+				defer s.mu.Unlock()
+				for _, q := range s.queues {
+					if len(q) > max {
+						max = len(q)
+					}
+				}
+			}, g)) {
+				xs = append(xs, x)
+			}
+			out <- xs
+		}(g)
+	}
+	expected := ToSlice(context.Background(), Limit(total, naturals()))
+	for i := 0; i < n; i++ {
+		require.Equal(t, expected, <-out)
+	}
+	require.LessOrEqual(t, max, cap)
+}
+
+func TestMemoize(t *testing.T) {
+	require.Nil(t, Memoize(nil))
+
+	n := 0
+	g := Memoize(Limit(3, Some(func() interface{} {
+		n++
+		return n
+	})))
+	require.Equal(t, []interface{}{1, 2, 3}, exhaust(g))
+	require.Equal(t, []interface{}{1, 2, 3}, exhaust(g))
+	require.Equal(t, 3, n)
+
+	t.Run("Lazy", func(t *testing.T) {
+		ctx := context.Background()
+		n := 0
+		g := Memoize(Some(func() interface{} {
+			n++
+			return n
+		}))
+		require.Equal(t, []interface{}{1, 2}, ToSlice(ctx, Limit(2, g)))
+		require.Equal(t, 2, n)
+		require.Equal(t, []interface{}{1, 2, 3, 4}, ToSlice(ctx, Limit(4, g)))
+		require.Equal(t, 4, n)
+		_, ng := g.Next(ctx)
+		require.Equal(t, []interface{}{2, 3}, ToSlice(ctx, Limit(2, ng)))
+		require.Equal(t, 4, n)
+	})
+
+	t.Run("Pending", func(t *testing.T) {
+		g := Memoize(Seq(1, Pending, 2))
+		require.Equal(t, []interface{}{1, Pending, 2}, exhaust(g))
+		require.Equal(t, []interface{}{1, 2}, exhaust(g))
+	})
+
+	t.Run("Mix", func(t *testing.T) {
+		g := Memoize(Mix(1, 2, 3, 4, 5, 6, 7, 8))
+		require.Equal(t, exhaust(g), exhaust(g))
+	})
+}
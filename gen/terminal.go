@@ -0,0 +1,257 @@
+package gen
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+)
+
+// drive pulls values from g and passes them to f until g stops, ctx is done or
+// f returns false. Pending values are skipped by updating the generator and
+// calling Next again.
+func drive(ctx context.Context, g Generator, f func(x interface{}) bool) error {
+	for g != nil {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		x, ng := g.Next(ctx)
+		if IsStopIteration(x) {
+			return nil
+		}
+		if IsPending(x) {
+			if ng != nil {
+				ng = ng.Update(ctx)
+			}
+			g = ng
+			continue
+		}
+		g = ng
+		if !f(x) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// ToSlice collects all values of g. Like other terminals in this package, it
+// never returns Pending: a Pending value is dropped, the generator is updated
+// and Next is called again. It returns what has been collected so far once ctx
+// is done.
+func ToSlice(ctx context.Context, g Generator) []interface{} {
+	var xs []interface{}
+	drive(ctx, g, func(x interface{}) bool {
+		xs = append(xs, x)
+		return true
+	})
+	return xs
+}
+
+// CollectMap collects all values of g into a map from keyFn(x) to valFn(x),
+// later keys overwrite earlier ones.
+func CollectMap(ctx context.Context, keyFn, valFn func(x interface{}) interface{}, g Generator) map[interface{}]interface{} {
+	m := make(map[interface{}]interface{})
+	drive(ctx, g, func(x interface{}) bool {
+		m[keyFn(x)] = valFn(x)
+		return true
+	})
+	return m
+}
+
+func ToSliceN(ctx context.Context, n int, g Generator) []interface{} {
+	if n <= 0 {
+		return nil
+	}
+	var xs []interface{}
+	drive(ctx, g, func(x interface{}) bool {
+		xs = append(xs, x)
+		return len(xs) < n
+	})
+	return xs
+}
+
+// ForEach calls f on each value of g until g stops, f returns an error or ctx
+// is done. Pending values are skipped as in ToSlice. f may return
+// StopIteration to stop early, in which case ForEach returns nil.
+func ForEach(ctx context.Context, g Generator, f func(x interface{}) error) error {
+	var err error
+	if e := drive(ctx, g, func(x interface{}) bool {
+		err = f(x)
+		return err == nil
+	}); e != nil {
+		return e
+	}
+	if IsStopIteration(err) {
+		return nil
+	}
+	return err
+}
+
+func Fold(ctx context.Context, seed interface{}, f func(acc, x interface{}) interface{}, g Generator) interface{} {
+	acc := seed
+	drive(ctx, g, func(x interface{}) bool {
+		acc = f(acc, x)
+		return true
+	})
+	return acc
+}
+
+// Reduce is an alias of Fold.
+func Reduce(ctx context.Context, seed interface{}, f func(acc, x interface{}) interface{}, g Generator) interface{} {
+	return Fold(ctx, seed, f, g)
+}
+
+// Sum adds up all numeric values of g as float64, values of other kinds are
+// ignored.
+func Sum(ctx context.Context, g Generator) float64 {
+	return Fold(ctx, .0, func(acc, x interface{}) interface{} {
+		if f, ok := toFloat64(x); ok {
+			return acc.(float64) + f
+		}
+		return acc
+	}, g).(float64)
+}
+
+// Count returns the number of values emitted by g. It drains g, so use it only
+// with finite generators, or ones capped by Limit or TimeLimit.
+func Count(ctx context.Context, g Generator) int64 {
+	return Fold(ctx, int64(0), func(acc, x interface{}) interface{} {
+		return acc.(int64) + 1
+	}, g).(int64)
+}
+
+func Max(ctx context.Context, less func(a, b interface{}) bool, g Generator) (interface{}, bool) {
+	return Min(ctx, func(a, b interface{}) bool { return less(b, a) }, g)
+}
+
+func Min(ctx context.Context, less func(a, b interface{}) bool, g Generator) (interface{}, bool) {
+	var (
+		min interface{}
+		ok  bool
+	)
+	drive(ctx, g, func(x interface{}) bool {
+		if !ok || less(x, min) {
+			min, ok = x, true
+		}
+		return true
+	})
+	return min, ok
+}
+
+func First(ctx context.Context, g Generator) (interface{}, bool) { return Nth(ctx, 0, g) }
+
+// Nth returns the nth (zero-based) value of g, Pending values are not counted.
+func Nth(ctx context.Context, n int, g Generator) (interface{}, bool) {
+	if n < 0 {
+		return nil, false
+	}
+	i := 0
+	return Find(ctx, func(interface{}) bool {
+		i++
+		return i > n
+	}, g)
+}
+
+// Last returns the last value of g. It drains g, so it never returns on an
+// infinite generator unless ctx is done, in which case the last value seen so
+// far is returned.
+func Last(ctx context.Context, g Generator) (interface{}, bool) {
+	var (
+		last interface{}
+		ok   bool
+	)
+	drive(ctx, g, func(x interface{}) bool {
+		last, ok = x, true
+		return true
+	})
+	return last, ok
+}
+
+// Find returns the first value of g satisfying f.
+func Find(ctx context.Context, f func(x interface{}) bool, g Generator) (interface{}, bool) {
+	var (
+		found interface{}
+		ok    bool
+	)
+	drive(ctx, g, func(x interface{}) bool {
+		if f(x) {
+			found, ok = x, true
+		}
+		return !ok
+	})
+	return found, ok
+}
+
+// Any reports whether some value of g satisfies f, it stops pulling at the
+// first one that does.
+func Any(ctx context.Context, f func(x interface{}) bool, g Generator) bool {
+	_, ok := Find(ctx, f, g)
+	return ok
+}
+
+// All reports whether every value of g satisfies f, it stops pulling at the
+// first one that doesn't.
+func All(ctx context.Context, f func(x interface{}) bool, g Generator) bool {
+	return !Any(ctx, func(x interface{}) bool { return !f(x) }, g)
+}
+
+// Contains reports whether g yields a value equal to target by ==. Values that
+// can't be compared with target, like those holding slices, never match.
+func Contains(ctx context.Context, target interface{}, g Generator) bool {
+	return Any(ctx, func(x interface{}) bool { return equal(x, target) }, g)
+}
+
+// equal is x == y, but false instead of panicking on incomparable values.
+func equal(x, y interface{}) (eq bool) {
+	defer func() {
+		if recover() != nil {
+			eq = false
+		}
+	}()
+	return x == y
+}
+
+// Sample returns k values picked uniformly at random from g by reservoir
+// sampling, so only k values are kept in memory. It drains g, and returns the
+// current sample once ctx is done.
+func Sample(ctx context.Context, k int, g Generator) []interface{} {
+	return SampleRand(ctx, nil, k, g)
+}
+
+func SampleRand(ctx context.Context, r *rand.Rand, k int, g Generator) []interface{} {
+	if k <= 0 {
+		return nil
+	}
+	r = randFrom(ctx, r)
+	var (
+		xs []interface{}
+		n  int64
+	)
+	drive(ctx, g, func(x interface{}) bool {
+		if n++; len(xs) < k {
+			xs = append(xs, x)
+		} else if i := randInt63n(r, n); i < int64(k) {
+			xs[i] = x
+		}
+		return true
+	})
+	return xs
+}
+
+func toFloat64(x interface{}) (float64, bool) {
+	if x == nil {
+		return 0, false
+	}
+	v := reflect.ValueOf(x)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	default:
+		return 0, false
+	}
+}
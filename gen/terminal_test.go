@@ -0,0 +1,282 @@
+package gen
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestToSlice(t *testing.T) {
+	ctx := context.Background()
+
+	for _, tt := range []struct {
+		name string
+		g    Generator
+		r    []interface{}
+	}{
+		{"Nil", nil, nil},
+		{"Seq", Seq(1, 2, 3), []interface{}{1, 2, 3}},
+		{"Pending", Seq(1, Pending, 2), []interface{}{1, 2}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.r, ToSlice(ctx, tt.g))
+		})
+	}
+
+	t.Run("Cancel", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		ch := make(chan interface{}, 1)
+		ch <- 1
+		require.Equal(t, []interface{}{1}, ToSlice(ctx, Some(ch)))
+	})
+}
+
+func TestToSliceN(t *testing.T) {
+	ctx := context.Background()
+
+	for _, tt := range []struct {
+		name string
+		n    int
+		g    Generator
+		r    []interface{}
+	}{
+		{"Nil", 3, nil, nil},
+		{"Zero", 0, Seq(1, 2), nil},
+		{"Short", 3, Seq(1, 2), []interface{}{1, 2}},
+		{"Infinite", 3, Repeat(Some(1)), []interface{}{1, 1, 1}},
+		{"Pending", 2, Repeat(Seq(Pending, 1)), []interface{}{1, 1}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.r, ToSliceN(ctx, tt.n, tt.g))
+		})
+	}
+}
+
+func TestCollectMap(t *testing.T) {
+	ctx := context.Background()
+	first := func(x interface{}) interface{} { return x.([2]interface{})[0] }
+	second := func(x interface{}) interface{} { return x.([2]interface{})[1] }
+
+	require.Equal(t, map[interface{}]interface{}{}, CollectMap(ctx, first, second, nil))
+	require.Equal(t,
+		map[interface{}]interface{}{"a": 3, "b": 2},
+		CollectMap(ctx, first, second, Seq([2]interface{}{"a", 1}, Pending, [2]interface{}{"b", 2}, [2]interface{}{"a", 3})))
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	ch := make(chan interface{}, 1)
+	ch <- 1
+	require.Equal(t, map[interface{}]interface{}{1: 1}, CollectMap(ctx, identity, identity, Some(ch)))
+}
+
+func TestForEach(t *testing.T) {
+	ctx := context.Background()
+
+	var xs []interface{}
+	collect := func(x interface{}) error {
+		xs = append(xs, x)
+		return nil
+	}
+	require.NoError(t, ForEach(ctx, nil, collect))
+	require.Nil(t, xs)
+	require.NoError(t, ForEach(ctx, Seq(1, Pending, 2), collect))
+	require.Equal(t, []interface{}{1, 2}, xs)
+
+	t.Run("Error", func(t *testing.T) {
+		oops := errors.New("oops")
+		n := 0
+		err := ForEach(ctx, Repeat(Some(1)), func(x interface{}) error {
+			if n++; n == 3 {
+				return oops
+			}
+			return nil
+		})
+		require.Equal(t, oops, err)
+		require.Equal(t, 3, n)
+	})
+
+	t.Run("Stop", func(t *testing.T) {
+		n := 0
+		err := ForEach(ctx, Repeat(Some(1)), func(x interface{}) error {
+			if n++; n == 3 {
+				return StopIteration
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 3, n)
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		err := ForEach(ctx, Some(make(chan interface{})), func(x interface{}) error { return nil })
+		require.Equal(t, context.DeadlineExceeded, err)
+	})
+}
+
+func TestFold(t *testing.T) {
+	ctx := context.Background()
+	concat := func(acc, x interface{}) interface{} { return acc.(string) + x.(string) }
+
+	require.Equal(t, "", Fold(ctx, "", concat, nil))
+	require.Equal(t, ">abc", Fold(ctx, ">", concat, Seq("a", "b", Pending, "c")))
+	require.Equal(t, ">abc", Reduce(ctx, ">", concat, Seq("a", "b", Pending, "c")))
+
+	t.Run("Cancel", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		ch := make(chan interface{}, 2)
+		ch <- "a"
+		ch <- "b"
+		require.Equal(t, "ab", Fold(ctx, "", concat, Some(ch)))
+	})
+}
+
+func TestSum(t *testing.T) {
+	ctx := context.Background()
+	require.Equal(t, .0, Sum(ctx, nil))
+	require.Equal(t, 6.5, Sum(ctx, Seq(1, int64(2), uint8(3), .5, "oops")))
+	require.Equal(t, 10.0, Sum(ctx, RangeF64(0, 5)))
+}
+
+func TestCount(t *testing.T) {
+	ctx := context.Background()
+	require.Equal(t, int64(0), Count(ctx, nil))
+	require.Equal(t, int64(3), Count(ctx, Seq(1, Pending, 2, nil, 3)))
+	require.Equal(t, int64(5), Count(ctx, Limit(5, Repeat(Some(1)))))
+	require.Equal(t, int64(0), Count(ctx, TimeLimit(10*time.Millisecond, Repeat(Some(Pending)))))
+}
+
+func TestMinMax(t *testing.T) {
+	ctx := context.Background()
+	less := func(a, b interface{}) bool { return a.(int) < b.(int) }
+
+	_, ok := Min(ctx, less, nil)
+	require.False(t, ok)
+	_, ok = Max(ctx, less, nil)
+	require.False(t, ok)
+
+	x, ok := Min(ctx, less, Seq(3, 1, Pending, 4, 1, 5))
+	require.True(t, ok)
+	require.Equal(t, 1, x)
+	x, ok = Max(ctx, less, Seq(3, 1, Pending, 4, 1, 5))
+	require.True(t, ok)
+	require.Equal(t, 5, x)
+}
+
+func TestFind(t *testing.T) {
+	ctx := context.Background()
+	even := func(x interface{}) bool { return x.(int)%2 == 0 }
+
+	for _, tt := range []struct {
+		name string
+		x    interface{}
+		ok   bool
+		f    func() (interface{}, bool)
+	}{
+		{"First", nil, false, func() (interface{}, bool) { return First(ctx, nil) }},
+		{"First", 1, true, func() (interface{}, bool) { return First(ctx, Seq(Pending, 1, 2)) }},
+		{"Nth", 3, true, func() (interface{}, bool) { return Nth(ctx, 2, Seq(1, Pending, 2, 3, 4)) }},
+		{"Nth", nil, false, func() (interface{}, bool) { return Nth(ctx, 2, Seq(1, 2)) }},
+		{"Nth", nil, false, func() (interface{}, bool) { return Nth(ctx, -1, Seq(1, 2)) }},
+		{"Nth", 5, true, func() (interface{}, bool) { return Nth(ctx, 5, naturals()) }},
+		{"Last", nil, false, func() (interface{}, bool) { return Last(ctx, nil) }},
+		{"Last", 3, true, func() (interface{}, bool) { return Last(ctx, Seq(1, 2, 3, Pending)) }},
+		{"Find", 4, true, func() (interface{}, bool) { return Find(ctx, even, Seq(1, 3, Pending, 4, 6)) }},
+		{"Find", nil, false, func() (interface{}, bool) { return Find(ctx, even, Seq(1, 3)) }},
+		{"Find", 0, true, func() (interface{}, bool) { return Find(ctx, even, naturals()) }},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			x, ok := tt.f()
+			require.Equal(t, tt.ok, ok)
+			require.Equal(t, tt.x, x)
+		})
+	}
+
+	t.Run("Cancel", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		x, ok := Last(ctx, naturals())
+		require.True(t, ok)
+		require.Greater(t, x, 0)
+	})
+
+	t.Run("Lazy", func(t *testing.T) {
+		n := 0
+		g := Tap(func(interface{}) { n++ }, naturals())
+		x, ok := Nth(ctx, 3, g)
+		require.True(t, ok)
+		require.Equal(t, 3, x)
+		require.Equal(t, 4, n)
+	})
+}
+
+func TestAnyAll(t *testing.T) {
+	ctx := context.Background()
+	even := func(x interface{}) bool { return x.(int)%2 == 0 }
+
+	require.False(t, Any(ctx, even, nil))
+	require.True(t, All(ctx, even, nil))
+	require.True(t, Any(ctx, even, Seq(1, Pending, 2)))
+	require.False(t, Any(ctx, even, Seq(1, Pending, 3)))
+	require.True(t, All(ctx, even, Seq(2, Pending, 4)))
+	require.False(t, All(ctx, even, Seq(2, Pending, 3)))
+	require.True(t, Any(ctx, even, naturals()))
+	require.False(t, All(ctx, even, naturals()))
+}
+
+func TestContains(t *testing.T) {
+	ctx := context.Background()
+
+	require.False(t, Contains(ctx, 1, nil))
+	require.True(t, Contains(ctx, 2, Seq(1, Pending, 2)))
+	require.False(t, Contains(ctx, int64(2), Seq(1, 2)))
+	require.True(t, Contains(ctx, "b", Seq(1, "a", []int{1}, "b")))
+	require.False(t, Contains(ctx, []int{1}, Seq([]int{1})))
+	require.False(t, Contains(ctx, [1]interface{}{[]int{1}}, Seq([1]interface{}{[]int{1}})))
+	require.True(t, Contains(ctx, [1]interface{}{1}, Seq([1]interface{}{[]int{1}}, [1]interface{}{1})))
+	require.True(t, Contains(ctx, 100, naturals()))
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	require.False(t, Contains(ctx, -1, naturals()))
+}
+
+func TestSample(t *testing.T) {
+	ctx := context.Background()
+
+	require.Nil(t, Sample(ctx, 0, Seq(1, 2)))
+	require.Nil(t, Sample(ctx, 2, nil))
+	require.Equal(t, []interface{}{1, 2}, Sample(ctx, 3, Seq(1, Pending, 2)))
+
+	sample := func(seed int64) []interface{} {
+		return SampleRand(ctx, rand.New(rand.NewSource(seed)), 5, Limit(1000, naturals()))
+	}
+	xs := sample(42)
+	require.Len(t, xs, 5)
+	require.Equal(t, xs, sample(42))
+	require.NotEqual(t, []interface{}{0, 1, 2, 3, 4}, xs)
+
+	cnt := make([]int, 10)
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		for _, x := range SampleRand(ctx, r, 2, Limit(10, naturals())) {
+			cnt[x.(int)]++
+		}
+	}
+	for _, c := range cnt {
+		require.InDelta(t, 200, c, 60)
+	}
+
+	t.Run("Cancel", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		require.Len(t, Sample(ctx, 3, naturals()), 3)
+	})
+}
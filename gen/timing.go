@@ -0,0 +1,335 @@
+package gen
+
+import (
+	"context"
+	"time"
+)
+
+func Retry(n int, g Generator) Generator { return RetryDelay(n, 0, g) }
+
+// RetryDelay updates g and calls Next again, up to n times, when it yields
+// Pending, waiting d before each retry. The last Pending is forwarded once
+// retries are used up or ctx is done.
+func RetryDelay(n int, d time.Duration, g Generator) Generator {
+	if g == nil || n <= 0 {
+		return g
+	}
+	return retry{g, n, d}
+}
+
+type retry struct {
+	inner Generator
+	n     int
+	d     time.Duration
+}
+
+func (g retry) Update(ctx context.Context) Generator {
+	if g.inner == nil {
+		return nil
+	}
+	return RetryDelay(g.n, g.d, g.inner.Update(ctx))
+}
+
+func (g retry) Next(ctx context.Context) (interface{}, Generator) {
+	if g.inner == nil {
+		return StopIteration, nil
+	}
+	x, ng := g.inner.Next(ctx)
+	for i := 0; i < g.n && IsPending(x) && ng != nil && ctx.Err() == nil; i++ {
+		if g.d > 0 {
+			select {
+			case <-ctx.Done():
+				return x, RetryDelay(g.n, g.d, ng)
+			case <-time.After(g.d):
+			}
+		}
+		if ng = ng.Update(ctx); ng == nil {
+			return StopIteration, nil
+		}
+		x, ng = ng.Next(ctx)
+	}
+	if IsStopIteration(x) {
+		return StopIteration, nil
+	}
+	return x, RetryDelay(g.n, g.d, ng)
+}
+
+// Drive hides Pending values of g by updating it and calling Next again until
+// it yields a value or stops. Pending is only forwarded once ctx is done, in
+// which case ctx.Err() tells why.
+func Drive(g Generator) Generator { return DriveWith(nil, g) }
+
+// DriveWith is like Drive but waits backoff(attempt) before each retry, where
+// attempt counts the consecutive Pending values so far, starting from 1.
+func DriveWith(backoff func(attempt int) time.Duration, g Generator) Generator {
+	if g == nil {
+		return nil
+	}
+	return driver{g, backoff}
+}
+
+type driver struct {
+	inner   Generator
+	backoff func(int) time.Duration
+}
+
+func (g driver) Update(ctx context.Context) Generator {
+	if g.inner == nil {
+		return nil
+	}
+	return DriveWith(g.backoff, g.inner.Update(ctx))
+}
+
+func (g driver) Next(ctx context.Context) (interface{}, Generator) {
+	if g.inner == nil {
+		return StopIteration, nil
+	}
+	x, ng := g.inner.Next(ctx)
+	for attempt := 1; IsPending(x) && ng != nil; attempt++ {
+		if ctx.Err() != nil {
+			return x, DriveWith(g.backoff, ng)
+		}
+		if g.backoff != nil {
+			if d := g.backoff(attempt); d > 0 {
+				select {
+				case <-ctx.Done():
+					return x, DriveWith(g.backoff, ng)
+				case <-time.After(d):
+				}
+			}
+		}
+		if ng = ng.Update(ctx); ng == nil {
+			return StopIteration, nil
+		}
+		x, ng = ng.Next(ctx)
+	}
+	if IsStopIteration(x) || IsPending(x) {
+		return StopIteration, nil
+	}
+	return x, DriveWith(g.backoff, ng)
+}
+
+// RateLimit yields values of g at an average rate of at most rate values per
+// second, allowing bursts of up to burst values, as a token bucket starting
+// full. Next blocks until a token is available, or yields Pending if ctx is
+// done first. Pending values of g don't consume tokens. It returns nil if rate
+// or burst isn't positive.
+func RateLimit(rate float64, burst int, g Generator) Generator {
+	if g == nil || !(rate > 0) || burst <= 0 {
+		return nil
+	}
+	return rateLimit{inner: g, rate: rate, burst: burst, tokens: float64(burst)}
+}
+
+// Throttle spaces values of g at least interval apart, it is RateLimit with a
+// burst of 1.
+func Throttle(interval time.Duration, g Generator) Generator {
+	if interval <= 0 {
+		return g
+	}
+	return RateLimit(float64(time.Second)/float64(interval), 1, g)
+}
+
+type rateLimit struct {
+	inner  Generator
+	rate   float64
+	burst  int
+	tokens float64
+	last   time.Time
+}
+
+func (g rateLimit) generator() Generator {
+	if g.inner == nil {
+		return nil
+	}
+	return g
+}
+
+func (g rateLimit) Update(ctx context.Context) Generator {
+	if g.inner != nil {
+		g.inner = g.inner.Update(ctx)
+	}
+	return g.generator()
+}
+
+func (g rateLimit) Next(ctx context.Context) (interface{}, Generator) {
+	if g.inner == nil {
+		return StopIteration, nil
+	}
+	now := time.Now()
+	if !g.last.IsZero() {
+		g.tokens += g.rate * now.Sub(g.last).Seconds()
+		if g.tokens > float64(g.burst) {
+			g.tokens = float64(g.burst)
+		}
+	}
+	g.last = now
+	if g.tokens < 1 {
+		d := time.Duration((1 - g.tokens) / g.rate * float64(time.Second))
+		t := time.NewTimer(d)
+		defer t.Stop()
+		select {
+		case <-ctx.Done():
+			return Pending, g
+		case now = <-t.C:
+		}
+		g.tokens, g.last = 1, now
+	}
+	x, ng := g.inner.Next(ctx)
+	if IsStopIteration(x) {
+		return StopIteration, nil
+	}
+	if !IsPending(x) {
+		g.tokens--
+	}
+	g.inner = ng
+	return x, g.generator()
+}
+
+// Debounce yields a value of g only after d has passed without g yielding a
+// newer one, values superseded within d are dropped. Being pull-based, the
+// quiet window is measured while Next waits on g, by passing it a ctx with a
+// deadline, so this only makes sense for sources produced concurrently, e.g.
+// channels. Pending values of g are forwarded while the window keeps running,
+// and the last value is yielded as soon as g stops.
+func Debounce(d time.Duration, g Generator) Generator {
+	if g == nil || d <= 0 {
+		return g
+	}
+	return debounce{inner: g, d: d}
+}
+
+type debounce struct {
+	inner    Generator
+	d        time.Duration
+	x        interface{}
+	held     bool
+	deadline time.Time
+}
+
+func (g debounce) generator() Generator {
+	if g.inner == nil && !g.held {
+		return nil
+	}
+	return g
+}
+
+func (g debounce) Update(ctx context.Context) Generator {
+	if g.inner != nil {
+		g.inner = g.inner.Update(ctx)
+	}
+	return g.generator()
+}
+
+func (g debounce) Next(ctx context.Context) (interface{}, Generator) {
+	for g.inner != nil {
+		x, ng := g.nextInner(ctx)
+		if IsStopIteration(x) {
+			g.inner = nil
+			break
+		}
+		if IsPending(x) {
+			if g.held && ctx.Err() == nil && !time.Now().Before(g.deadline) {
+				return g.x, debounce{inner: ng, d: g.d}.generator()
+			}
+			g.inner = ng
+			return x, g.generator()
+		}
+		g.inner, g.x, g.held, g.deadline = ng, x, true, time.Now().Add(g.d)
+	}
+	if g.held {
+		return g.x, nil
+	}
+	return StopIteration, nil
+}
+
+func (g debounce) nextInner(ctx context.Context) (interface{}, Generator) {
+	if !g.held {
+		return g.inner.Next(ctx)
+	}
+	ctx, cancel := context.WithDeadline(ctx, g.deadline)
+	defer cancel()
+	return g.inner.Next(ctx)
+}
+
+// SampleEvery yields the latest value of g once every interval, dropping the
+// values superseded in between. Between ticks, Next keeps pulling g with a ctx
+// whose deadline is the next tick, so a value counts as available if g yields
+// it before that deadline. Nothing is yielded for an interval without values,
+// and the last value is yielded on the next tick after g stops.
+func SampleEvery(interval time.Duration, g Generator) Generator {
+	if g == nil || interval <= 0 {
+		return g
+	}
+	return sampleEvery{inner: g, interval: interval}
+}
+
+type sampleEvery struct {
+	inner    Generator
+	interval time.Duration
+	tick     time.Time
+	x        interface{}
+	held     bool
+}
+
+func (g sampleEvery) generator() Generator {
+	if g.inner == nil && !g.held {
+		return nil
+	}
+	return g
+}
+
+func (g sampleEvery) Update(ctx context.Context) Generator {
+	if g.inner != nil {
+		g.inner = g.inner.Update(ctx)
+	}
+	return g.generator()
+}
+
+func (g sampleEvery) Next(ctx context.Context) (interface{}, Generator) {
+	if g.tick.IsZero() {
+		g.tick = time.Now().Add(g.interval)
+	}
+	for g.inner != nil {
+		if now := time.Now(); !now.Before(g.tick) {
+			g.advance(now)
+			if g.held {
+				x := g.x
+				g.x, g.held = nil, false
+				return x, g.generator()
+			}
+		}
+		cctx, cancel := context.WithDeadline(ctx, g.tick)
+		x, ng := g.inner.Next(cctx)
+		cancel()
+		if IsStopIteration(x) {
+			g.inner = nil
+			break
+		}
+		g.inner = ng
+		if IsPending(x) {
+			if ctx.Err() != nil || time.Now().Before(g.tick) {
+				return x, g.generator()
+			}
+			continue
+		}
+		g.x, g.held = x, true
+	}
+	if !g.held {
+		return StopIteration, nil
+	}
+	t := time.NewTimer(time.Until(g.tick))
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return Pending, g
+	case <-t.C:
+		return g.x, nil
+	}
+}
+
+func (g *sampleEvery) advance(now time.Time) {
+	for !g.tick.After(now) {
+		g.tick = g.tick.Add(g.interval)
+	}
+}
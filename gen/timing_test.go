@@ -0,0 +1,217 @@
+package gen
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+
+	require.Nil(t, Retry(1, nil))
+	g := Seq(1)
+	require.Equal(t, g, Retry(0, g))
+
+	for _, tt := range []struct {
+		name string
+		g    Generator
+		r    []interface{}
+	}{
+		{"Retry", Retry(2, Seq(Pending, Pending, 1, Pending, 2)), []interface{}{1, 2}},
+		{"Exceed", Retry(1, Seq(Pending, Pending, 1)), []interface{}{Pending, 1}},
+		{"Stop", Retry(2, Seq(1, Pending)), []interface{}{1, Pending}},
+		{"Delay", RetryDelay(2, time.Millisecond, Seq(Pending, 1)), []interface{}{1}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.r, exhaust(tt.g))
+		})
+	}
+
+	t.Run("Cancel", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		x, g := RetryDelay(100, time.Millisecond, Repeat(Some(Pending))).Next(ctx)
+		require.True(t, IsPending(x))
+		require.NotNil(t, g)
+		require.Less(t, int64(time.Since(start)), int64(100*time.Millisecond))
+	})
+}
+
+func TestDrive(t *testing.T) {
+	require.Nil(t, Drive(nil))
+
+	for _, tt := range []struct {
+		name string
+		g    Generator
+		r    []interface{}
+	}{
+		{"Drive", Drive(Seq(Pending, Pending, 1, Pending, 2)), []interface{}{1, 2}},
+		{"Stop", Drive(Seq(1, Pending)), []interface{}{1}},
+		{"Backoff", DriveWith(func(int) time.Duration { return time.Millisecond }, Seq(Pending, 1)), []interface{}{1}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.r, exhaust(tt.g))
+		})
+	}
+
+	t.Run("Attempts", func(t *testing.T) {
+		var attempts []int
+		backoff := func(i int) time.Duration {
+			attempts = append(attempts, i)
+			return 0
+		}
+		require.Equal(t, []interface{}{1, 2}, exhaust(DriveWith(backoff, Seq(Pending, Pending, 1, Pending, 2))))
+		require.Equal(t, []int{1, 2, 1}, attempts)
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		x, g := DriveWith(func(int) time.Duration { return time.Millisecond }, Repeat(Some(Pending))).Next(ctx)
+		require.True(t, IsPending(x))
+		require.NotNil(t, g)
+		require.Error(t, ctx.Err())
+		require.Less(t, int64(time.Since(start)), int64(time.Second))
+	})
+}
+
+func TestRateLimit(t *testing.T) {
+	require.Nil(t, RateLimit(1, 1, nil))
+	require.Nil(t, RateLimit(0, 1, Seq(1)))
+	require.Nil(t, RateLimit(1, 0, Seq(1)))
+
+	require.Equal(t, []interface{}{1, Pending, 2}, exhaust(RateLimit(1000, 1, Seq(1, Pending, 2))))
+
+	t.Run("Rate", func(t *testing.T) {
+		start := time.Now()
+		xs := exhaust(RateLimit(200, 1, Limit(11, naturals())))
+		require.Len(t, xs, 11)
+		require.GreaterOrEqual(t, int64(time.Since(start)), int64(45*time.Millisecond))
+	})
+
+	t.Run("Burst", func(t *testing.T) {
+		start := time.Now()
+		xs := exhaust(RateLimit(1, 5, Limit(5, naturals())))
+		require.Len(t, xs, 5)
+		require.Less(t, int64(time.Since(start)), int64(500*time.Millisecond))
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		g := RateLimit(.1, 1, naturals())
+		x, g := g.Next(ctx)
+		require.Equal(t, 0, x)
+		start := time.Now()
+		x, g = g.Next(ctx)
+		require.True(t, IsPending(x))
+		require.NotNil(t, g)
+		require.Less(t, int64(time.Since(start)), int64(time.Second))
+	})
+}
+
+func TestThrottle(t *testing.T) {
+	require.Nil(t, Throttle(time.Millisecond, nil))
+	g := Seq(1)
+	require.Equal(t, g, Throttle(0, g))
+
+	const n, interval = 10, 5 * time.Millisecond
+	start := time.Now()
+	require.Equal(t, ToSlice(context.Background(), Limit(n, naturals())), exhaust(Throttle(interval, Limit(n, naturals()))))
+	elapsed := time.Since(start)
+	require.GreaterOrEqual(t, int64(elapsed), int64((n-1)*interval))
+	require.Less(t, int64(elapsed), int64(10*n*interval))
+}
+
+func TestDebounce(t *testing.T) {
+	require.Nil(t, Debounce(time.Millisecond, nil))
+	g := Seq(1)
+	require.Equal(t, g, Debounce(0, g))
+
+	require.Equal(t, []interface{}{3}, exhaust(Debounce(time.Second, Seq(1, 2, 3))))
+	require.Equal(t, []interface{}{Pending, 2}, exhaust(Debounce(time.Second, Seq(1, Pending, 2))))
+
+	t.Run("Quiet", func(t *testing.T) {
+		ctx := context.Background()
+		g := Debounce(10*time.Millisecond, Seq(1, Pending, Pending, 2))
+		x, g := g.Next(ctx)
+		require.True(t, IsPending(x))
+		time.Sleep(20 * time.Millisecond)
+		x, g = g.Update(ctx).Next(ctx)
+		require.Equal(t, 1, x)
+		require.Equal(t, []interface{}{2}, exhaust(g))
+	})
+
+	t.Run("Burst", func(t *testing.T) {
+		ch := make(chan interface{})
+		go func() {
+			defer close(ch)
+			for _, burst := range [][]interface{}{{1, 2, 3}, {4, 5}} {
+				for _, x := range burst {
+					ch <- x
+				}
+				time.Sleep(100 * time.Millisecond)
+			}
+		}()
+		require.Equal(t, []interface{}{3, 5}, ToSlice(context.Background(), Debounce(20*time.Millisecond, Some(ch))))
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		ch := make(chan interface{}, 1)
+		ch <- 1
+		x, g := Debounce(time.Second, Some(ch)).Next(ctx)
+		require.True(t, IsPending(x))
+		require.NotNil(t, g)
+		close(ch)
+		x, _ = g.Next(context.Background())
+		require.Equal(t, 1, x)
+	})
+}
+
+func TestSampleEvery(t *testing.T) {
+	require.Nil(t, SampleEvery(time.Millisecond, nil))
+	g := Seq(1)
+	require.Equal(t, g, SampleEvery(0, g))
+
+	start := time.Now()
+	require.Equal(t, []interface{}{3}, exhaust(SampleEvery(10*time.Millisecond, Seq(1, 2, 3))))
+	require.GreaterOrEqual(t, int64(time.Since(start)), int64(10*time.Millisecond))
+
+	t.Run("Infinite", func(t *testing.T) {
+		start := time.Now()
+		xs := ToSlice(context.Background(), Limit(3, SampleEvery(10*time.Millisecond, naturals())))
+		require.Len(t, xs, 3)
+		require.Less(t, xs[0], xs[1])
+		require.Less(t, xs[1], xs[2])
+		require.GreaterOrEqual(t, int64(time.Since(start)), int64(30*time.Millisecond))
+	})
+
+	t.Run("Channel", func(t *testing.T) {
+		ch := make(chan interface{})
+		go func() {
+			defer close(ch)
+			for _, burst := range [][]interface{}{{1, 2, 3}, {4, 5}} {
+				for _, x := range burst {
+					ch <- x
+				}
+				time.Sleep(50 * time.Millisecond)
+			}
+		}()
+		require.Equal(t, []interface{}{3, 5}, ToSlice(context.Background(), SampleEvery(20*time.Millisecond, Some(ch))))
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		x, g := SampleEvery(time.Second, Some(make(chan interface{}))).Next(ctx)
+		require.True(t, IsPending(x))
+		require.NotNil(t, g)
+	})
+}
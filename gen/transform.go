@@ -0,0 +1,544 @@
+package gen
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+func TakeWhile(f func(x interface{}) bool, g Generator) Generator {
+	if g == nil {
+		return nil
+	}
+	return takeWhile{g, f}
+}
+
+type takeWhile struct {
+	inner Generator
+	f     func(interface{}) bool
+}
+
+func (g takeWhile) Update(ctx context.Context) Generator {
+	if g.inner == nil {
+		return nil
+	}
+	return TakeWhile(g.f, g.inner.Update(ctx))
+}
+
+func (g takeWhile) Next(ctx context.Context) (interface{}, Generator) {
+	if g.inner == nil {
+		return StopIteration, nil
+	}
+	x, ng := g.inner.Next(ctx)
+	if IsStopIteration(x) || !(IsPending(x) || g.f(x)) {
+		return StopIteration, nil
+	}
+	return x, TakeWhile(g.f, ng)
+}
+
+func DropWhile(f func(x interface{}) bool, g Generator) Generator {
+	if g == nil {
+		return nil
+	}
+	return dropWhile{g, f}
+}
+
+type dropWhile struct {
+	inner Generator
+	f     func(interface{}) bool
+}
+
+func (g dropWhile) Update(ctx context.Context) Generator {
+	if g.inner == nil {
+		return nil
+	}
+	return DropWhile(g.f, g.inner.Update(ctx))
+}
+
+func (g dropWhile) Next(ctx context.Context) (interface{}, Generator) {
+	for g.inner != nil {
+		x, ng := g.inner.Next(ctx)
+		if IsStopIteration(x) {
+			break
+		}
+		if IsPending(x) {
+			return x, DropWhile(g.f, ng)
+		}
+		if !g.f(x) {
+			return x, ng
+		}
+		g.inner = ng
+	}
+	return StopIteration, nil
+}
+
+// Step yields every nth value of g, starting from the first one.
+func Step(n int, g Generator) Generator {
+	if g == nil || n <= 0 {
+		return nil
+	}
+	return step{g, n, 0}
+}
+
+type step struct {
+	inner Generator
+	n     int
+	skip  int
+}
+
+func (g step) generator() Generator {
+	if g.inner == nil {
+		return nil
+	}
+	return g
+}
+
+func (g step) Update(ctx context.Context) Generator {
+	if g.inner == nil {
+		return nil
+	}
+	g.inner = g.inner.Update(ctx)
+	return g.generator()
+}
+
+func (g step) Next(ctx context.Context) (interface{}, Generator) {
+	for g.inner != nil {
+		x, ng := g.inner.Next(ctx)
+		if IsStopIteration(x) {
+			break
+		}
+		g.inner = ng
+		if IsPending(x) {
+			return x, g.generator()
+		}
+		if g.skip > 0 {
+			g.skip--
+			continue
+		}
+		g.skip = g.n - 1
+		return x, g.generator()
+	}
+	return StopIteration, nil
+}
+
+// Skip drops the first n values of g.
+func Skip(n int, g Generator) Generator {
+	if g == nil || n <= 0 {
+		return g
+	}
+	return skip{g, n}
+}
+
+type skip struct {
+	inner Generator
+	n     int
+}
+
+func (g skip) Update(ctx context.Context) Generator {
+	if g.inner == nil {
+		return nil
+	}
+	return Skip(g.n, g.inner.Update(ctx))
+}
+
+func (g skip) Next(ctx context.Context) (interface{}, Generator) {
+	for g.inner != nil {
+		x, ng := g.inner.Next(ctx)
+		if IsStopIteration(x) {
+			break
+		}
+		if IsPending(x) {
+			return x, Skip(g.n, ng)
+		}
+		if g.n <= 0 {
+			return x, ng
+		}
+		g.inner, g.n = ng, g.n-1
+	}
+	return StopIteration, nil
+}
+
+func Dedup(g Generator) Generator { return DedupBy(identity, g) }
+
+func identity(x interface{}) interface{} { return x }
+
+func DedupBy(key func(x interface{}) interface{}, g Generator) Generator {
+	if g == nil {
+		return nil
+	}
+	return dedup{inner: g, key: key}
+}
+
+type dedup struct {
+	inner Generator
+	key   func(interface{}) interface{}
+	last  interface{}
+	seen  bool
+}
+
+func (g dedup) generator() Generator {
+	if g.inner == nil {
+		return nil
+	}
+	return g
+}
+
+func (g dedup) Update(ctx context.Context) Generator {
+	if g.inner == nil {
+		return nil
+	}
+	g.inner = g.inner.Update(ctx)
+	return g.generator()
+}
+
+func (g dedup) Next(ctx context.Context) (interface{}, Generator) {
+	for g.inner != nil {
+		x, ng := g.inner.Next(ctx)
+		if IsStopIteration(x) {
+			break
+		}
+		g.inner = ng
+		if IsPending(x) {
+			return x, g.generator()
+		}
+		k := g.key(x)
+		if g.seen && reflect.DeepEqual(k, g.last) {
+			continue
+		}
+		g.last, g.seen = k, true
+		return x, g.generator()
+	}
+	return StopIteration, nil
+}
+
+// Distinct drops values that have been emitted before. Every emitted value is
+// kept in a set, so memory grows with the number of distinct values; use
+// DistinctN to bound it. Values that cannot be used as map keys are compared by
+// their type and %v representation. The set is shared by all generators
+// derived from the returned one, so replaying an earlier state won't emit
+// values again.
+func Distinct(g Generator) Generator { return DistinctBy(identity, g) }
+
+// DistinctBy is like Distinct but compares values by key(x).
+func DistinctBy(key func(x interface{}) interface{}, g Generator) Generator {
+	if g == nil {
+		return nil
+	}
+	return distinct{g, key, newSeenSet(0)}
+}
+
+// DistinctN is like Distinct but only remembers the n most recently seen values.
+func DistinctN(n int, g Generator) Generator {
+	if g == nil || n <= 0 {
+		return nil
+	}
+	return distinct{g, identity, newSeenSet(n)}
+}
+
+type distinct struct {
+	inner Generator
+	key   func(interface{}) interface{}
+	seen  *seenSet
+}
+
+func (g distinct) Update(ctx context.Context) Generator {
+	if g.inner == nil {
+		return nil
+	}
+	ng := g.inner.Update(ctx)
+	if ng == nil {
+		return nil
+	}
+	return distinct{ng, g.key, g.seen}
+}
+
+func (g distinct) Next(ctx context.Context) (interface{}, Generator) {
+	for g.inner != nil {
+		x, ng := g.inner.Next(ctx)
+		if IsStopIteration(x) {
+			break
+		}
+		if ng != nil {
+			ng = distinct{ng, g.key, g.seen}
+		}
+		if IsPending(x) || g.seen.add(setKey(g.key(x))) {
+			return x, ng
+		}
+		if ng == nil {
+			break
+		}
+		g = ng.(distinct)
+	}
+	return StopIteration, nil
+}
+
+// Flatten inlines values of g that are generators themselves (recursively),
+// other values are passed through verbatim.
+func Flatten(g Generator) Generator { return FlatMap(flatten, g) }
+
+func flatten(x interface{}) Generator {
+	if g, ok := x.(Generator); ok {
+		return Flatten(g)
+	}
+	return some{x}
+}
+
+// Tap calls f on each value of g for its side effects, Pending values are not
+// passed to f.
+func Tap(f func(x interface{}), g Generator) Generator {
+	if g == nil {
+		return nil
+	}
+	return tap{g, f}
+}
+
+type tap struct {
+	inner Generator
+	f     func(interface{})
+}
+
+func (g tap) Update(ctx context.Context) Generator {
+	if g.inner == nil {
+		return nil
+	}
+	return Tap(g.f, g.inner.Update(ctx))
+}
+
+func (g tap) Next(ctx context.Context) (interface{}, Generator) {
+	if g.inner == nil {
+		return StopIteration, nil
+	}
+	x, ng := g.inner.Next(ctx)
+	if IsStopIteration(x) {
+		return StopIteration, nil
+	}
+	if !IsPending(x) {
+		g.f(x)
+	}
+	return x, Tap(g.f, ng)
+}
+
+// OnStop calls f once g is exhausted, that is when it stops, is updated to nil
+// or yields its last value. f is called at most once, even if an earlier state
+// of the returned generator is replayed to the end again. A done ctx only makes
+// g yield Pending, so it doesn't count as the end of g, nor does abandoning
+// the generator before it ends: f is not called in these cases. If g is nil,
+// f is called right away.
+func OnStop(g Generator, f func()) Generator {
+	if g == nil {
+		f()
+		return nil
+	}
+	return onStop{g, f, new(sync.Once)}
+}
+
+type onStop struct {
+	inner Generator
+	f     func()
+	once  *sync.Once
+}
+
+func (g onStop) generator(inner Generator) Generator {
+	if inner == nil {
+		g.once.Do(g.f)
+		return nil
+	}
+	return onStop{inner, g.f, g.once}
+}
+
+func (g onStop) Update(ctx context.Context) Generator {
+	if g.inner == nil {
+		return g.generator(nil)
+	}
+	return g.generator(g.inner.Update(ctx))
+}
+
+func (g onStop) Next(ctx context.Context) (interface{}, Generator) {
+	if g.inner == nil {
+		return StopIteration, g.generator(nil)
+	}
+	x, ng := g.inner.Next(ctx)
+	if IsStopIteration(x) {
+		return StopIteration, g.generator(nil)
+	}
+	return x, g.generator(ng)
+}
+
+type Indexed struct {
+	Index int64
+	Value interface{}
+}
+
+// Enumerate wraps each value of g as Indexed, counting from 0. Unlike zipping g
+// with RangeI64, the index only advances on emitted values, so Pending values
+// are passed through unwrapped and never consume an index.
+func Enumerate(g Generator) Generator { return EnumerateFrom(0, g) }
+
+func EnumerateFrom(start int64, g Generator) Generator {
+	if g == nil {
+		return nil
+	}
+	return enumerate{g, start}
+}
+
+type enumerate struct {
+	inner Generator
+	index int64
+}
+
+func (g enumerate) Update(ctx context.Context) Generator {
+	if g.inner == nil {
+		return nil
+	}
+	return EnumerateFrom(g.index, g.inner.Update(ctx))
+}
+
+func (g enumerate) Next(ctx context.Context) (interface{}, Generator) {
+	if g.inner == nil {
+		return StopIteration, nil
+	}
+	x, ng := g.inner.Next(ctx)
+	if IsStopIteration(x) {
+		return StopIteration, nil
+	}
+	if IsPending(x) {
+		return x, EnumerateFrom(g.index, ng)
+	}
+	return Indexed{g.index, x}, EnumerateFrom(g.index+1, ng)
+}
+
+// EnumeratePairs is like Enumerate but yields [2]interface{}{int64(i), x}
+// pairs, the shape Zip(RangeI64(), g) yields.
+func EnumeratePairs(g Generator) Generator { return EnumeratePairsFrom(0, g) }
+
+func EnumeratePairsFrom(start int64, g Generator) Generator {
+	return Map(indexedPair, EnumerateFrom(start, g))
+}
+
+func indexedPair(x interface{}) interface{} {
+	if ix, ok := x.(Indexed); ok {
+		return [2]interface{}{ix.Index, ix.Value}
+	}
+	return x
+}
+
+type seenSet struct {
+	mu   sync.Mutex
+	keys map[interface{}]*list.Element
+	lru  *list.List
+	cap  int
+}
+
+func newSeenSet(cap int) *seenSet {
+	s := &seenSet{keys: make(map[interface{}]*list.Element), cap: cap}
+	if cap > 0 {
+		s.lru = list.New()
+	}
+	return s
+}
+
+func (s *seenSet) add(k interface{}) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.keys[k]; ok {
+		if s.lru != nil {
+			s.lru.MoveToFront(e)
+		}
+		return false
+	}
+	var e *list.Element
+	if s.lru != nil {
+		e = s.lru.PushFront(k)
+		if s.lru.Len() > s.cap {
+			delete(s.keys, s.lru.Remove(s.lru.Back()))
+		}
+	}
+	s.keys[k] = e
+	return true
+}
+
+type sprinted struct {
+	t reflect.Type
+	s string
+}
+
+func setKey(x interface{}) (k interface{}) {
+	if x == nil {
+		return nil
+	}
+	defer func() {
+		if recover() != nil {
+			k = sprinted{reflect.TypeOf(x), fmt.Sprintf("%v", x)}
+		}
+	}()
+	_ = x == x
+	return x
+}
+
+// Recover recovers panics raised by g. When Next panics, it yields
+// handler(recovered) instead, or Pending if that is nil, and stops: the state g
+// was in before the panic is all that is left, and replaying it would most
+// likely panic again. Use RecoverRetry for generators that may succeed when
+// retried. A panic in Update is recovered by keeping g as it was.
+func Recover(g Generator, handler func(recovered interface{}) interface{}) Generator {
+	if g == nil {
+		return nil
+	}
+	return recoverer{g, handler, false}
+}
+
+// RecoverRetry is like Recover but the next call retries g from the state it
+// was in before the panic, which suits generators driven by funcs with side
+// effects, like Some(f). The handler may return StopIteration to stop instead.
+// A g that panics every time it is retried never ends.
+func RecoverRetry(g Generator, handler func(recovered interface{}) interface{}) Generator {
+	if g == nil {
+		return nil
+	}
+	return recoverer{g, handler, true}
+}
+
+type recoverer struct {
+	inner Generator
+	h     func(interface{}) interface{}
+	retry bool
+}
+
+func (g recoverer) wrap(inner Generator) Generator {
+	if inner == nil {
+		return nil
+	}
+	return recoverer{inner, g.h, g.retry}
+}
+
+func (g recoverer) Update(ctx context.Context) (ng Generator) {
+	if g.inner == nil {
+		return nil
+	}
+	defer func() {
+		if recover() != nil {
+			ng = g
+		}
+	}()
+	return g.wrap(g.inner.Update(ctx))
+}
+
+func (g recoverer) Next(ctx context.Context) (x interface{}, ng Generator) {
+	if g.inner == nil {
+		return StopIteration, nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			if x, ng = g.h(r), nil; x == nil {
+				x = Pending
+			}
+			if g.retry && !IsStopIteration(x) {
+				ng = g
+			}
+		}
+	}()
+	x, ng = g.inner.Next(ctx)
+	return x, g.wrap(ng)
+}
@@ -0,0 +1,349 @@
+package gen
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func lessThan(n int) func(x interface{}) bool {
+	return func(x interface{}) bool { return x.(int) < n }
+}
+
+func TestTakeWhile(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		g    Generator
+		r    []interface{}
+	}{
+		{"Nil", TakeWhile(lessThan(3), nil), nil},
+		{"All", TakeWhile(lessThan(3), Seq(1, 2)), []interface{}{1, 2}},
+		{"None", TakeWhile(lessThan(3), Seq(3, 1)), nil},
+		{"Prefix", TakeWhile(lessThan(3), Seq(1, 2, 3, 1)), []interface{}{1, 2}},
+		{"Infinite", TakeWhile(lessThan(5), naturals()), []interface{}{0, 1, 2, 3, 4}},
+		{"Pending", TakeWhile(lessThan(3), Seq(1, Pending, 2, 3)), []interface{}{1, Pending, 2}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.r, exhaust(tt.g))
+		})
+	}
+}
+
+func TestWhileTimeLimit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, g := range []Generator{
+		TakeWhile(lessThan(3), TimeLimit(time.Second, Seq(1, 2, 3))),
+		DropWhile(lessThan(1), TimeLimit(time.Second, Seq(1, 2, 3))),
+	} {
+		x, g := g.Next(ctx)
+		require.True(t, IsPending(x))
+		require.NotNil(t, g)
+		g = g.Update(context.Background())
+		require.NotNil(t, g)
+		x, _ = g.Next(context.Background())
+		require.Equal(t, 1, x)
+	}
+
+	exhausted := Choices{{Some(1), 0}}
+	require.Nil(t, TakeWhile(lessThan(3), exhausted).Update(ctx))
+	require.Nil(t, DropWhile(lessThan(3), exhausted).Update(ctx))
+}
+
+func TestDropWhile(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		g    Generator
+		r    []interface{}
+	}{
+		{"Nil", DropWhile(lessThan(3), nil), nil},
+		{"All", DropWhile(lessThan(3), Seq(1, 2)), nil},
+		{"None", DropWhile(lessThan(3), Seq(3, 1)), []interface{}{3, 1}},
+		{"Prefix", DropWhile(lessThan(3), Seq(1, 2, 3, 1)), []interface{}{3, 1}},
+		{"Pending", DropWhile(lessThan(3), Seq(1, Pending, 2, 3, Pending)), []interface{}{Pending, 3, Pending}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.r, exhaust(tt.g))
+		})
+	}
+}
+
+func naturals() Generator {
+	return Map(func(x interface{}) interface{} { return int(x.(int64)) }, RangeI64())
+}
+
+func TestStep(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		g    Generator
+		r    []interface{}
+	}{
+		{"Nil", Step(2, nil), nil},
+		{"Zero", Step(0, Seq(1)), nil},
+		{"One", Step(1, Seq(1, 2, 3)), []interface{}{1, 2, 3}},
+		{"Step", Step(2, Seq(1, 2, 3, 4, 5)), []interface{}{1, 3, 5}},
+		{"Step", Step(3, Seq(1, 2, 3, 4, 5)), []interface{}{1, 4}},
+		{"Infinite", Limit(3, Step(10, naturals())), []interface{}{0, 10, 20}},
+		{"Pending", Step(2, Seq(1, Pending, 2, Pending, 3)), []interface{}{1, Pending, Pending, 3}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.r, exhaust(tt.g))
+		})
+	}
+}
+
+func TestSkip(t *testing.T) {
+	g := Seq(1)
+	require.Equal(t, g, Skip(0, g))
+
+	for _, tt := range []struct {
+		name string
+		g    Generator
+		r    []interface{}
+	}{
+		{"Nil", Skip(2, nil), nil},
+		{"Skip", Skip(2, Seq(1, 2, 3, 4)), []interface{}{3, 4}},
+		{"All", Skip(5, Seq(1, 2, 3)), nil},
+		{"Infinite", Limit(2, Skip(10, naturals())), []interface{}{10, 11}},
+		{"Pending", Skip(1, Seq(Pending, 1, Pending, 2)), []interface{}{Pending, Pending, 2}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.r, exhaust(tt.g))
+		})
+	}
+}
+
+func TestDedup(t *testing.T) {
+	parity := func(x interface{}) interface{} { return x.(int) % 2 }
+
+	for _, tt := range []struct {
+		name string
+		g    Generator
+		r    []interface{}
+	}{
+		{"Nil", Dedup(nil), nil},
+		{"Dedup", Dedup(Seq(1, 1, 2, 2, 2, 1, 3, 3)), []interface{}{1, 2, 1, 3}},
+		{"DeepEqual", Dedup(Seq([]int{1}, []int{1}, []int{2})), []interface{}{[]int{1}, []int{2}}},
+		{"Pending", Dedup(Seq(1, Pending, 1, 2, Pending, Pending, 2)), []interface{}{1, Pending, 2, Pending, Pending}},
+		{"Repeat", Limit(3, Dedup(Repeat(Seq(1, 1, 2)))), []interface{}{1, 2, 1}},
+		{"DedupBy", DedupBy(parity, Seq(1, 3, 2, 4, 5)), []interface{}{1, 2, 5}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.r, exhaust(tt.g))
+		})
+	}
+}
+
+func TestDistinct(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		g    Generator
+		r    []interface{}
+	}{
+		{"Nil", Distinct(nil), nil},
+		{"Distinct", Distinct(Seq(1, 2, 1, 3, 2, 4)), []interface{}{1, 2, 3, 4}},
+		{"Unhashable", Distinct(Seq([]int{1}, []int{2}, []int{1})), []interface{}{[]int{1}, []int{2}}},
+		{"Unhashable", Distinct(Seq([1]interface{}{[]int{1}}, [1]interface{}{[]int{1}})), []interface{}{[1]interface{}{[]int{1}}}},
+		{"Types", Distinct(Seq(1, int64(1), "1", []string{"1"})), []interface{}{1, int64(1), "1", []string{"1"}}},
+		{"Pending", Distinct(Seq(Pending, 1, Pending, 1)), []interface{}{Pending, 1, Pending}},
+		{"Infinite", Limit(3, Distinct(naturals())), []interface{}{0, 1, 2}},
+		{"DistinctBy", DistinctBy(func(x interface{}) interface{} { return len(x.([]int)) }, Seq([]int{1}, []int{2}, []int{1, 2})), []interface{}{[]int{1}, []int{1, 2}}},
+		{"DistinctN", DistinctN(0, Seq(1, 1)), nil},
+		{"DistinctN", DistinctN(2, Seq(1, 2, 1, 3, 1, 2, 4, 1)), []interface{}{1, 2, 3, 2, 4, 1}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.r, exhaust(tt.g))
+		})
+	}
+
+	t.Run("Shared", func(t *testing.T) {
+		g := Distinct(Seq(1, 2, 1, 3))
+		require.Equal(t, []interface{}{1, 2, 3}, exhaust(g))
+		require.Nil(t, exhaust(g))
+	})
+}
+
+func TestEnumerate(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		g    Generator
+		r    []interface{}
+	}{
+		{"Nil", Enumerate(nil), nil},
+		{"Enumerate", Enumerate(Seq("a", "b")), []interface{}{Indexed{0, "a"}, Indexed{1, "b"}}},
+		{"Pending", Enumerate(Seq("a", Pending, "b")), []interface{}{Indexed{0, "a"}, Pending, Indexed{1, "b"}}},
+		{"From", EnumerateFrom(10, Seq("a", "b")), []interface{}{Indexed{10, "a"}, Indexed{11, "b"}}},
+		{"From", Limit(2, EnumerateFrom(-1, Repeat(Seq(Pending, "a")))), []interface{}{Pending, Indexed{-1, "a"}}},
+		{"Filter", Enumerate(Filter(func(x interface{}) bool { return x.(int)%2 == 0 }, Seq(1, 2, 3, 4))), []interface{}{Indexed{0, 2}, Indexed{1, 4}}},
+		{"Pairs", EnumeratePairs(nil), nil},
+		{"Pairs", EnumeratePairs(Seq("a", Pending, "b")), []interface{}{[2]interface{}{int64(0), "a"}, Pending, [2]interface{}{int64(1), "b"}}},
+		{"Pairs", EnumeratePairsFrom(5, Seq("a")), exhaust(Zip(RangeI64(5), Seq("a")))},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.r, exhaust(tt.g))
+		})
+	}
+}
+
+func TestTap(t *testing.T) {
+	var xs []interface{}
+	collect := func(x interface{}) { xs = append(xs, x) }
+
+	require.Nil(t, Tap(collect, nil))
+	require.Equal(t, []interface{}{1, Pending, 2}, exhaust(Tap(collect, Seq(1, Pending, 2))))
+	require.Equal(t, []interface{}{1, 2}, xs)
+}
+
+func TestOnStop(t *testing.T) {
+	ctx := context.Background()
+	n := 0
+	f := func() { n++ }
+
+	require.Nil(t, OnStop(nil, f))
+	require.Equal(t, 1, n)
+
+	n = 0
+	g := OnStop(Seq(1, Pending, 2), f)
+	require.Equal(t, []interface{}{1, Pending, 2}, exhaust(g))
+	require.Equal(t, 1, n)
+	require.Equal(t, []interface{}{1, Pending, 2}, exhaust(g))
+	require.Equal(t, 1, n)
+
+	n = 0
+	g = OnStop(Some(closedChan()), f)
+	x, g := g.Next(ctx)
+	require.True(t, IsStopIteration(x))
+	require.Nil(t, g)
+	require.Equal(t, 1, n)
+
+	t.Run("Cancel", func(t *testing.T) {
+		n := 0
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		ch := make(chan interface{}, 1)
+		g := OnStop(Some(ch), func() { n++ })
+		x, g := g.Next(ctx)
+		require.True(t, IsPending(x))
+		require.Equal(t, 0, n)
+		close(ch)
+		x, g = g.Next(context.Background())
+		require.True(t, IsStopIteration(x))
+		require.Nil(t, g)
+		require.Equal(t, 1, n)
+	})
+}
+
+func TestFlatten(t *testing.T) {
+	f := func() int { return 42 }
+	src := func(xs ...interface{}) Generator {
+		ch := make(chan interface{}, len(xs))
+		for _, x := range xs {
+			ch <- x
+		}
+		close(ch)
+		return Some(ch)
+	}
+
+	for _, tt := range []struct {
+		name string
+		g    Generator
+		r    []interface{}
+	}{
+		{"Nil", Flatten(nil), nil},
+		{"Values", Flatten(src(1, nil, "a")), []interface{}{1, nil, "a"}},
+		{"Inline", Flatten(src(1, Seq(2, 3), 4)), []interface{}{1, 2, 3, 4}},
+		{"Nested", Flatten(src(Cons(Seq(1, 2), src(3, src(4))), 5)), []interface{}{1, 2, 3, 4, 5}},
+		{"Pending", Flatten(src(Seq(1, Pending), Pending, 2)), []interface{}{1, Pending, Pending, 2}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.r, exhaust(tt.g))
+		})
+	}
+
+	x, _ := Flatten(src(f)).Next(context.Background())
+	require.Equal(t, reflect.ValueOf(f), reflect.ValueOf(x))
+}
+
+func TestRecover(t *testing.T) {
+	ctx := context.Background()
+	oops := func(x interface{}) interface{} {
+		if x.(int) == 2 {
+			panic("oops")
+		}
+		return x
+	}
+	handler := func(r interface{}) interface{} { return r }
+
+	require.Nil(t, Recover(nil, handler))
+	require.Equal(t, []interface{}{1, 2}, exhaust(Recover(Seq(1, 2), handler)))
+
+	g := Recover(Map(oops, Seq(1, 2, 3)), handler)
+	x, g := g.Next(ctx)
+	require.Equal(t, 1, x)
+	x, g = g.Next(ctx)
+	require.Equal(t, "oops", x)
+	require.Nil(t, g)
+
+	always := Recover(Map(func(interface{}) interface{} { panic("always") }, Seq(1, 2, 3)), handler)
+	require.Equal(t, []interface{}{"always"}, ToSlice(ctx, always))
+	require.Equal(t, []interface{}{"always"}, ToSliceN(ctx, 6, always))
+
+	xs := exhaust(Recover(Map(oops, Seq(2)), func(interface{}) interface{} { return nil }))
+	require.Equal(t, []interface{}{Pending}, xs)
+
+	p := Recover(panicky{}, handler)
+	require.Equal(t, panicky{}, p.Update(ctx).(recoverer).inner)
+	x, g = p.Next(ctx)
+	require.Equal(t, "next", x)
+	require.Nil(t, g)
+
+	t.Run("Retry", func(t *testing.T) {
+		require.Nil(t, RecoverRetry(nil, handler))
+
+		g := RecoverRetry(Map(oops, Seq(1, 2, 3)), handler)
+		x, g := g.Next(ctx)
+		require.Equal(t, 1, x)
+		x, g = g.Next(ctx)
+		require.Equal(t, "oops", x)
+		x, _ = g.Next(ctx)
+		require.Equal(t, "oops", x)
+
+		x, _ = RecoverRetry(Map(oops, Seq(2)), func(interface{}) interface{} { return nil }).Next(ctx)
+		require.True(t, IsPending(x))
+
+		n := 0
+		g = RecoverRetry(Some(func() interface{} {
+			if n++; n%2 == 0 {
+				panic(n)
+			}
+			return n
+		}), handler)
+		require.Equal(t, []interface{}{1, 2, 3, 4}, exhaust(Limit(4, g)))
+
+		n = 0
+		third := Some(func() interface{} {
+			if n++; n == 3 {
+				panic("third")
+			}
+			return n
+		})
+		stop := func(interface{}) interface{} { return StopIteration }
+		require.Equal(t, []interface{}{1, 2}, exhaust(RecoverRetry(third, stop)))
+		n = 0
+		require.Equal(t, []interface{}{1, 2, "third", 4}, exhaust(Limit(4, RecoverRetry(third, handler))))
+
+		x, g = RecoverRetry(panicky{}, handler).Next(ctx)
+		require.Equal(t, "next", x)
+		require.Equal(t, panicky{}, g.(recoverer).inner)
+	})
+}
+
+type panicky struct{}
+
+func (panicky) Update(ctx context.Context) Generator { panic("update") }
+
+func (panicky) Next(ctx context.Context) (interface{}, Generator) { panic("next") }
@@ -0,0 +1,128 @@
+package typed
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/zyguan/xs/gen"
+)
+
+// Generator is a typed view of gen.Generator. Next reports ok=false instead of
+// returning StopIteration or Pending: the returned generator is nil once the
+// underlying generator has stopped, and non-nil if it is just pending, in which
+// case the caller may Update it and call Next again.
+type Generator[T any] interface {
+	Update(ctx context.Context) Generator[T]
+	Next(ctx context.Context) (T, Generator[T], bool)
+}
+
+// Lift converts g into a Generator[T]. Its Next panics if g yields a value
+// that is not a T.
+func Lift[T any](g gen.Generator) Generator[T] {
+	if g == nil {
+		return nil
+	}
+	return lifted[T]{g}
+}
+
+type lifted[T any] struct{ inner gen.Generator }
+
+func (g lifted[T]) Update(ctx context.Context) Generator[T] {
+	return Lift[T](g.inner.Update(ctx))
+}
+
+func (g lifted[T]) Next(ctx context.Context) (T, Generator[T], bool) {
+	var zero T
+	x, ng := g.inner.Next(ctx)
+	if gen.IsStopIteration(x) {
+		return zero, nil, false
+	}
+	if gen.IsPending(x) {
+		return zero, Lift[T](ng), false
+	}
+	return cast[T](x), Lift[T](ng), true
+}
+
+func cast[T any](x interface{}) T {
+	if v, ok := x.(T); ok {
+		return v
+	}
+	var zero T
+	if x != nil {
+		panic(fmt.Sprintf("typed: %T is not a %T", x, zero))
+	}
+	return zero
+}
+
+// Lower converts g back into a gen.Generator.
+func Lower[T any](g Generator[T]) gen.Generator {
+	switch g := g.(type) {
+	case nil:
+		return nil
+	case lifted[T]:
+		return g.inner
+	default:
+		return lowered[T]{g}
+	}
+}
+
+type lowered[T any] struct{ inner Generator[T] }
+
+func (g lowered[T]) Update(ctx context.Context) gen.Generator {
+	return Lower(g.inner.Update(ctx))
+}
+
+func (g lowered[T]) Next(ctx context.Context) (interface{}, gen.Generator) {
+	x, ng, ok := g.inner.Next(ctx)
+	if ok {
+		return x, Lower(ng)
+	}
+	if ng == nil {
+		return gen.StopIteration, nil
+	}
+	return gen.Pending, Lower(ng)
+}
+
+func Some[T any](x T) Generator[T] {
+	return Lift[T](gen.FromSlice([]interface{}{x}))
+}
+
+func Seq[T any](xs ...T) Generator[T] {
+	ys := make([]interface{}, len(xs))
+	for i, x := range xs {
+		ys[i] = x
+	}
+	return Lift[T](gen.FromSlice(ys))
+}
+
+// Collect drains g into a slice, see gen.ToSlice.
+func Collect[T any](ctx context.Context, g Generator[T]) []T {
+	xs := gen.ToSlice(ctx, Lower(g))
+	if xs == nil {
+		return nil
+	}
+	ys := make([]T, len(xs))
+	for i, x := range xs {
+		ys[i] = cast[T](x)
+	}
+	return ys
+}
+
+func Map[A, B any](f func(x A) B, g Generator[A]) Generator[B] {
+	return Lift[B](gen.Map(func(x interface{}) interface{} {
+		if gen.IsPending(x) {
+			return x
+		}
+		return f(cast[A](x))
+	}, Lower(g)))
+}
+
+func Filter[T any](f func(x T) bool, g Generator[T]) Generator[T] {
+	return Lift[T](gen.Filter(func(x interface{}) bool {
+		return gen.IsPending(x) || f(cast[T](x))
+	}, Lower(g)))
+}
+
+func Limit[T any](n int, g Generator[T]) Generator[T] {
+	return Lift[T](gen.Limit(n, Lower(g)))
+}
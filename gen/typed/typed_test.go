@@ -0,0 +1,96 @@
+package typed
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zyguan/xs/gen"
+)
+
+func exhaust[T any](g Generator[T]) []T {
+	ctx := context.Background()
+	var (
+		xs []T
+		x  T
+		ok bool
+	)
+	for g != nil {
+		if x, g, ok = g.Next(ctx); ok {
+			xs = append(xs, x)
+		} else if g != nil {
+			g = g.Update(ctx)
+		}
+	}
+	return xs
+}
+
+func TestLift(t *testing.T) {
+	ctx := context.Background()
+
+	require.Nil(t, Lift[int](nil))
+	require.Equal(t, []int{1, 2, 3}, exhaust(Lift[int](gen.Seq(1, 2, 3))))
+	require.Equal(t, []error{nil}, exhaust(Lift[error](gen.FromSlice([]interface{}{nil}))))
+
+	t.Run("Pending", func(t *testing.T) {
+		x, g, ok := Lift[int](gen.Seq(gen.Pending, 1)).Next(ctx)
+		require.False(t, ok)
+		require.Zero(t, x)
+		require.NotNil(t, g)
+		x, g, ok = g.Next(ctx)
+		require.True(t, ok)
+		require.Equal(t, 1, x)
+		require.Nil(t, g)
+	})
+
+	t.Run("Mismatch", func(t *testing.T) {
+		require.Panics(t, func() { Lift[int](gen.Some("1")).Next(ctx) })
+	})
+}
+
+func TestLower(t *testing.T) {
+	g := gen.Seq(1, 2)
+	require.Nil(t, Lower[int](nil))
+	require.Equal(t, g, Lower(Lift[int](g)))
+
+	xs := gen.ToSlice(context.Background(), Lower(Map(strconv.Itoa, Lift[int](gen.Seq(1, gen.Pending, 2)))))
+	require.Equal(t, []interface{}{"1", "2"}, xs)
+}
+
+func TestSome(t *testing.T) {
+	f := func() int { return 1 }
+	require.Equal(t, []int{1}, exhaust(Some(1)))
+	require.Equal(t, []error{nil}, exhaust(Some[error](nil)))
+	require.Len(t, exhaust(Some(f)), 1)
+}
+
+func TestSeq(t *testing.T) {
+	require.Nil(t, Seq[int]())
+	require.Equal(t, []string{"a", "", "b"}, exhaust(Seq("a", "", "b")))
+}
+
+func TestCollect(t *testing.T) {
+	ctx := context.Background()
+	require.Nil(t, Collect[int](ctx, nil))
+	require.Equal(t, []int{1, 2, 3}, Collect(ctx, Seq(1, 2, 3)))
+	require.Equal(t, []int{1, 2}, Collect(ctx, Lift[int](gen.Seq(1, gen.Pending, 2))))
+}
+
+func TestMap(t *testing.T) {
+	g := Map(strconv.Itoa, Lift[int](gen.Seq(1, gen.Pending, 2)))
+	require.Equal(t, []string{"1", "2"}, exhaust(g))
+	require.Nil(t, Map(strconv.Itoa, nil))
+}
+
+func TestFilter(t *testing.T) {
+	even := func(x int) bool { return x%2 == 0 }
+	g := Filter(even, Lift[int](gen.Seq(1, 2, gen.Pending, 3, 4)))
+	require.Equal(t, []int{2, 4}, exhaust(g))
+}
+
+func TestLimit(t *testing.T) {
+	g := Limit(3, Lift[int](gen.Repeat(gen.Some(1))))
+	require.Equal(t, []int{1, 1, 1}, exhaust(g))
+	require.Nil(t, Limit(0, g))
+}
@@ -0,0 +1,167 @@
+package rule
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+)
+
+var ErrCycle = errors.New("rule: cyclic rule")
+
+// Count returns the number of productions Walk would visit for root without
+// enumerating them. It returns ErrCycle if root references itself, directly or
+// indirectly, since the count would be infinite then.
+func Count(root Rule) (*big.Int, error) {
+	c := counter{memo: make(map[interface{}]*big.Int), visiting: make(map[interface{}]bool)}
+	n, err := c.rule(root)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).Set(n), nil
+}
+
+type counter struct {
+	memo     map[interface{}]*big.Int
+	visiting map[interface{}]bool
+}
+
+func (c *counter) rule(r Rule) (*big.Int, error) {
+	if r == nil {
+		return big.NewInt(1), nil
+	}
+	k, ok := identity(r)
+	if ok {
+		if n, ok := c.memo[k]; ok {
+			return n, nil
+		}
+		if c.visiting[k] {
+			return nil, ErrCycle
+		}
+		c.visiting[k] = true
+		defer delete(c.visiting, k)
+	}
+	as := r.Alts()
+	if len(as) == 0 {
+		// Walk skips a rule without alternatives, like an empty one.
+		return big.NewInt(1), nil
+	}
+	n := new(big.Int)
+	for _, a := range as {
+		m, err := c.alt(a)
+		if err != nil {
+			return nil, err
+		}
+		n.Add(n, m)
+	}
+	if ok {
+		c.memo[k] = n
+	}
+	return n, nil
+}
+
+func (c *counter) alt(a Alt) (*big.Int, error) {
+	n := big.NewInt(1)
+	if a == nil {
+		return n, nil
+	}
+	for _, e := range a.Elems() {
+		if e == nil || !e.IsRule() {
+			continue
+		}
+		m, err := c.rule(e.Rule())
+		if err != nil {
+			return nil, err
+		}
+		n.Mul(n, m)
+	}
+	return n, nil
+}
+
+type sliceKey struct {
+	t reflect.Type
+	p uintptr
+	n int
+}
+
+// identity returns a map key identifying x: pointers, maps and channels by
+// the address they point to, slices (like the rules built by R) by their
+// backing array, and other values by themselves. ok is false if x can't be
+// identified, like a struct holding a slice in an interface field.
+func identity(x interface{}) (k interface{}, ok bool) {
+	v := reflect.ValueOf(x)
+	switch v.Kind() {
+	case reflect.Slice:
+		return sliceKey{v.Type(), v.Pointer(), v.Len()}, true
+	case reflect.Ptr, reflect.Map, reflect.Chan:
+		return sliceKey{v.Type(), v.Pointer(), -1}, true
+	}
+	if !v.Type().Comparable() {
+		return nil, false
+	}
+	defer func() {
+		if recover() != nil {
+			k, ok = nil, false
+		}
+	}()
+	_ = map[interface{}]struct{}{x: {}}
+	return x, true
+}
+
+// At returns the nth (zero-based) production in the order Walk visits them,
+// without walking the ones before it. ok is false if n is out of range, or if
+// root is cyclic.
+func At(root Rule, n *big.Int) (xs []interface{}, ok bool) {
+	c := counter{memo: make(map[interface{}]*big.Int), visiting: make(map[interface{}]bool)}
+	total, err := c.rule(root)
+	if err != nil || n.Sign() < 0 || n.Cmp(total) >= 0 {
+		return nil, false
+	}
+	return c.atRule(root, new(big.Int).Set(n), []interface{}{}), true
+}
+
+// atRule appends the nth production of r to xs, r must have been counted
+// already.
+func (c *counter) atRule(r Rule, n *big.Int, xs []interface{}) []interface{} {
+	if r == nil {
+		return xs
+	}
+	for _, a := range r.Alts() {
+		m, _ := c.alt(a)
+		if n.Cmp(m) < 0 {
+			return c.atAlt(a, n, xs)
+		}
+		n.Sub(n, m)
+	}
+	return xs
+}
+
+// atAlt appends the nth production of a to xs, earlier elements are the more
+// significant digits of n.
+func (c *counter) atAlt(a Alt, n *big.Int, xs []interface{}) []interface{} {
+	if a == nil {
+		return xs
+	}
+	elems := a.Elems()
+	counts := make([]*big.Int, len(elems))
+	suffix := big.NewInt(1)
+	for i := len(elems) - 1; i >= 0; i-- {
+		counts[i] = new(big.Int).Set(suffix)
+		if e := elems[i]; e != nil && e.IsRule() {
+			m, _ := c.rule(e.Rule())
+			suffix.Mul(suffix, m)
+		}
+	}
+	q := new(big.Int)
+	for i, e := range elems {
+		if e == nil {
+			continue
+		}
+		if !e.IsRule() {
+			xs = append(xs, e.Value())
+			continue
+		}
+		q.QuoRem(n, counts[i], n)
+		xs = c.atRule(e.Rule(), new(big.Int).Set(q), xs)
+	}
+	return xs
+}
@@ -0,0 +1,162 @@
+package rule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SyntaxError is returned by Parse for malformed input, Offset is the byte
+// offset in the input where the error was detected.
+type SyntaxError struct {
+	Offset int
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("rule: syntax error at offset %d: %s", e.Offset, e.Msg)
+}
+
+// Parse builds a rule from the notation written by String: alternatives are
+// separated by |, elements by spaces, parentheses group a nested rule and ε (or
+// nothing at all) stands for an empty alternative. Strings must be quoted as Go
+// string literals, bare values are parsed as ints, floats or bools. String of
+// the result gives back s up to spacing and ε, so Parse(String(r)) walks like r
+// as long as r has no cycle and its values are strings, ints, bools or floats
+// that are not whole numbers (those are parsed as ints).
+func Parse(s string) (Rule, error) {
+	p := parser{s: s}
+	r, err := p.rule()
+	if err != nil {
+		return nil, err
+	}
+	if p.skip(); p.pos < len(p.s) {
+		return nil, p.errorf("unexpected %q", p.s[p.pos])
+	}
+	return r, nil
+}
+
+type parser struct {
+	s   string
+	pos int
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return &SyntaxError{p.pos, fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) skip() {
+	for p.pos < len(p.s) {
+		c, n := utf8.DecodeRuneInString(p.s[p.pos:])
+		if !unicode.IsSpace(c) {
+			return
+		}
+		p.pos += n
+	}
+}
+
+// rule parses alternatives up to the end of input or a closing parenthesis.
+func (p *parser) rule() (Rule, error) {
+	var as []Alt
+	for {
+		a, err := p.alt()
+		if err != nil {
+			return nil, err
+		}
+		as = append(as, a)
+		if p.pos == len(p.s) || p.s[p.pos] != '|' {
+			return R(as...), nil
+		}
+		p.pos++
+	}
+}
+
+func (p *parser) alt() (Alt, error) {
+	elems := []Elem{}
+	for {
+		p.skip()
+		if p.pos == len(p.s) {
+			return A(elems...), nil
+		}
+		start := p.pos
+		switch p.s[p.pos] {
+		case '|', ')':
+			return A(elems...), nil
+		case '(':
+			p.pos++
+			r, err := p.rule()
+			if err != nil {
+				return nil, err
+			}
+			if p.pos == len(p.s) {
+				return nil, p.errorf("unclosed ( at offset %d", start)
+			}
+			p.pos++
+			elems = append(elems, E(r))
+		case '"':
+			x, err := p.quoted()
+			if err != nil {
+				return nil, err
+			}
+			elems = append(elems, V(x))
+		default:
+			x, ok, err := p.bare()
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				elems = append(elems, V(x))
+			}
+		}
+	}
+}
+
+func (p *parser) quoted() (string, error) {
+	start := p.pos
+	for i := p.pos + 1; i < len(p.s); i++ {
+		switch p.s[i] {
+		case '\\':
+			i++
+		case '"':
+			x, err := strconv.Unquote(p.s[start : i+1])
+			if err != nil {
+				return "", p.errorf("invalid string %s", p.s[start:i+1])
+			}
+			p.pos = i + 1
+			return x, nil
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+// bare parses a value that is not quoted, ok is false for ε.
+func (p *parser) bare() (x interface{}, ok bool, err error) {
+	end := p.pos
+	for end < len(p.s) {
+		c, n := utf8.DecodeRuneInString(p.s[end:])
+		if unicode.IsSpace(c) || strings.ContainsRune(`|()"`, c) {
+			break
+		}
+		end += n
+	}
+	tok := p.s[p.pos:end]
+	if tok == "ε" {
+		p.pos = end
+		return nil, false, nil
+	}
+	if x, err := strconv.Atoi(tok); err == nil {
+		p.pos = end
+		return x, true, nil
+	}
+	if x, err := strconv.ParseFloat(tok, 64); err == nil {
+		p.pos = end
+		return x, true, nil
+	}
+	if tok == "true" || tok == "false" {
+		p.pos = end
+		return tok == "true", true, nil
+	}
+	return nil, false, p.errorf("unexpected %q, strings must be quoted", tok)
+}
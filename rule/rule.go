@@ -1,5 +1,10 @@
 package rule
 
+import (
+	"fmt"
+	"strings"
+)
+
 // Rule -> Alt1 | Alt2 | ...
 type Rule interface {
 	Alts() []Alt
@@ -88,60 +93,217 @@ func Empty() Alt {
 	return A()
 }
 
+// Optional matches x zero or one time.
+func Optional(x interface{}) Rule { return OneOf(Empty(), x) }
+
+// Times matches x exactly n times.
+func Times(n int, x interface{}) Rule {
+	if n < 0 {
+		n = 0
+	}
+	xs := make([]interface{}, n)
+	for i := range xs {
+		xs[i] = x
+	}
+	return Seq(xs...)
+}
+
+// Between matches x from min to max times, fewer repetitions come first. A
+// negative min is taken as 0, and a max less than min as min.
+func Between(min, max int, x interface{}) Rule {
+	if min < 0 {
+		min = 0
+	}
+	if max < min {
+		max = min
+	}
+	xs := make([]interface{}, 0, max-min+1)
+	for n := min; n <= max; n++ {
+		xs = append(xs, Times(n, x))
+	}
+	return OneOf(xs...)
+}
+
+// Star matches x zero to max times, the bound is required since Walk enumerates
+// all productions.
+func Star(max int, x interface{}) Rule { return Between(0, max, x) }
+
+// Plus matches x one to max times.
+func Plus(max int, x interface{}) Rule { return Between(1, max, x) }
+
 func Walk(root Rule, cb func(...interface{})) {
-	type end int
+	WalkUntil(root, func(xs ...interface{}) bool {
+		cb(xs...)
+		return false
+	})
+}
+
+// WalkUntil is like Walk but stops as soon as cb returns true.
+func WalkUntil(root Rule, cb func(...interface{}) bool) { walk(root, nil, cb) }
+
+// WalkPrune is like Walk but calls keep with the values produced so far each
+// time the walk is about to expand an alternative, and skips all productions
+// of that alternative if it returns false. keep must not retain prefix.
+func WalkPrune(root Rule, keep func(prefix []interface{}) bool, cb func(...interface{})) {
+	walk(root, keep, func(xs ...interface{}) bool {
+		cb(xs...)
+		return false
+	})
+}
+
+// walk enumerates productions depth first. Instead of copying the rest of the
+// production for every alternative of a rule, each choice point refers to a
+// cursor on where the production continues, so the suffix is shared by all of
+// them. Cursors are kept on a stack: backtracking to a choice point discards
+// everything pushed after it.
+func walk(root Rule, keep func([]interface{}) bool, cb func(...interface{}) bool) {
+	type cursor struct {
+		elems []Elem
+		i     int
+		up    int
+	}
+	type choice struct {
+		alts []Alt
+		k    int
+		cont int
+		n    int
+		top  int
+	}
 
 	state := make([]interface{}, 0, 64)
-	remaining := []interface{}{end(0), root}
+	conts := make([]cursor, 0, 64)
+	var choices []choice
 
-	pop := func() interface{} {
-		size := len(remaining)
-		if size == 0 {
-			return nil
+	enter := func(a Alt, cont int) (cursor, bool) {
+		if a == nil {
+			return cursor{up: cont}, true
+		}
+		if keep != nil && !keep(state) {
+			return cursor{}, false
 		}
-		last := remaining[size-1]
-		remaining = remaining[:size-1]
-		return last
+		return cursor{a.Elems(), 0, cont}, true
 	}
-	lastRegion := func() (int, int) {
-		for i := len(remaining) - 1; i >= 0; i-- {
-			if _, ok := remaining[i].(end); ok {
-				return i, len(remaining)
+	backtrack := func() (cursor, bool) {
+		for len(choices) > 0 {
+			c := &choices[len(choices)-1]
+			state, conts = state[:c.n], conts[:c.top]
+			a, cont := c.alts[c.k], c.cont
+			if c.k++; c.k == len(c.alts) {
+				choices = choices[:len(choices)-1]
+			}
+			if cur, ok := enter(a, cont); ok {
+				return cur, true
 			}
 		}
-		return -1, -1
+		return cursor{}, false
+	}
+	// push saves cur as the continuation of what is entered next.
+	push := func(cur cursor) int {
+		if cur.i == len(cur.elems) {
+			return cur.up
+		}
+		conts = append(conts, cur)
+		return len(conts) - 1
 	}
 
-	for len(remaining) != 0 {
-		switch v := pop().(type) {
-		case Rule:
-			i, j := lastRegion()
-			alts := v.Alts()
-			size := len(alts)
-			if size == 0 {
+	cur, ok := cursor{[]Elem{E(root)}, 0, -1}, true
+	for {
+		if cur.i == len(cur.elems) {
+			if cur.up >= 0 {
+				cur = conts[cur.up]
 				continue
 			}
-			remaining = append(remaining, alts[size-1])
-			for k := size - 2; k >= 0; k-- {
-				remaining = append(remaining, end(len(state)))
-				remaining = append(remaining, remaining[i+1:j]...)
-				remaining = append(remaining, alts[k])
+			if cb(state...) {
+				return
 			}
-		case Alt:
-			elems := v.Elems()
-			size := len(elems)
-			for k := size - 1; k >= 0; k-- {
-				remaining = append(remaining, elems[k])
+			if cur, ok = backtrack(); !ok {
+				return
 			}
-		case Elem:
-			if v.IsRule() {
-				remaining = append(remaining, v.Rule())
-			} else {
-				state = append(state, v.Value())
+			continue
+		}
+		var x interface{} = cur.elems[cur.i]
+		cur.i++
+		for x != nil {
+			switch v := x.(type) {
+			case Rule:
+				x = nil
+				alts := v.Alts()
+				if len(alts) == 0 {
+					continue
+				}
+				cont := push(cur)
+				if len(alts) > 1 {
+					choices = append(choices, choice{alts, 1, cont, len(state), len(conts)})
+				}
+				cur, ok = enter(alts[0], cont)
+			case Alt:
+				x = nil
+				cur, ok = enter(v, push(cur))
+			case Elem:
+				if x = nil; v.IsRule() {
+					x = v.Rule()
+				} else {
+					state = append(state, v.Value())
+				}
+			default:
+				x = nil
 			}
-		case end:
-			cb(state...)
-			state = state[:int(v)]
+		}
+		if !ok {
+			if cur, ok = backtrack(); !ok {
+				return
+			}
+		}
+	}
+}
+
+// WalkString is like Walk but passes each production to cb as a string, with
+// its values formatted by fmt.Sprint (strings are used as is) and joined by sep.
+func WalkString(root Rule, sep string, cb func(string)) {
+	var sb strings.Builder
+	Walk(root, func(xs ...interface{}) {
+		sb.Reset()
+		join(&sb, sep, xs)
+		cb(sb.String())
+	})
+}
+
+func join(sb *strings.Builder, sep string, xs []interface{}) {
+	for i, x := range xs {
+		if i > 0 {
+			sb.WriteString(sep)
+		}
+		if s, ok := x.(string); ok {
+			sb.WriteString(s)
+		} else {
+			fmt.Fprint(sb, x)
 		}
 	}
 }
+
+// WalkConcat is WalkString without separator.
+func WalkConcat(root Rule, cb func(string)) { WalkString(root, "", cb) }
+
+// WalkDistinct is like Walk but skips productions that have been passed to cb
+// before. Productions are compared by their values formatted as in WalkString,
+// so 1 and "1" are the same value. Every distinct production is kept in a set
+// until the walk ends, so memory grows with the number of them; use
+// WalkDistinctSet to manage the set.
+func WalkDistinct(root Rule, cb func(...interface{})) {
+	WalkDistinctSet(root, make(map[string]struct{}), cb)
+}
+
+// WalkDistinctSet is like WalkDistinct but records productions in seen, which
+// may be shared by several walks or pre-filled to exclude productions.
+func WalkDistinctSet(root Rule, seen map[string]struct{}, cb func(...interface{})) {
+	var sb strings.Builder
+	Walk(root, func(xs ...interface{}) {
+		sb.Reset()
+		join(&sb, "\x00", xs)
+		if _, ok := seen[sb.String()]; ok {
+			return
+		}
+		seen[sb.String()] = struct{}{}
+		cb(xs...)
+	})
+}
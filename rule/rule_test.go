@@ -1,6 +1,11 @@
 package rule
 
-import "fmt"
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+	"testing"
+)
 
 func echo(xs ...interface{}) { fmt.Printf("%+v\n", xs) }
 
@@ -36,3 +41,243 @@ func ExampleRule3() {
 	// [1 3 4]
 	// [1 3]
 }
+
+func ExampleWalkUntil() {
+	r := Seq(OneOf(1, 2, 3), OneOf(4, 5))
+	n := 0
+	WalkUntil(r, func(xs ...interface{}) bool {
+		echo(xs...)
+		n++
+		return n == 3
+	})
+	// Output:
+	// [1 4]
+	// [1 5]
+	// [2 4]
+}
+
+func ExampleCount() {
+	digit := OneOf(0, 1, 2, 3, 4, 5, 6, 7, 8, 9)
+	r := Seq(digit, digit, OneOf(Empty(), digit))
+	fmt.Println(Count(r))
+
+	as := make([]Alt, 2)
+	loop := R(as...)
+	as[0], as[1] = A(V("x")), A(V("x"), E(loop))
+	fmt.Println(Count(loop))
+	// Output:
+	// 1100 <nil>
+	// <nil> rule: cyclic rule
+}
+
+type named struct {
+	name string
+	r    Rule
+}
+
+func (n named) Alts() []Alt { return n.r.Alts() }
+
+func Example_customRule() {
+	bit := named{"bit", OneOf(0, 1)}
+	r := Seq(bit, bit)
+	fmt.Println(Count(r))
+	fmt.Println(At(r, big.NewInt(2)))
+	fmt.Println(Validate(r))
+	fmt.Println(String(r))
+	// Output:
+	// 4 <nil>
+	// [1 0] true
+	// <nil>
+	// (0 | 1) (0 | 1)
+}
+
+func ExampleAt() {
+	r := Seq(
+		OneOf(Empty(), 1),
+		OneOf(2, 3),
+		OneOf(4, Empty()),
+	)
+	for i := int64(0); ; i++ {
+		xs, ok := At(r, big.NewInt(i))
+		if !ok {
+			break
+		}
+		echo(xs...)
+	}
+	// Output:
+	// [2 4]
+	// [2]
+	// [3 4]
+	// [3]
+	// [1 2 4]
+	// [1 2]
+	// [1 3 4]
+	// [1 3]
+}
+
+func ExampleSample() {
+	r := Seq(OneOf(1, Seq(2, OneOf(3, 4, 5))), OneOf(6, 7))
+	rnd := rand.New(rand.NewSource(42))
+	cnt := make(map[string]int)
+	for i := 0; i < 8000; i++ {
+		xs, _ := Sample(r, rnd)
+		cnt[fmt.Sprint(xs)]++
+	}
+	n, _ := Count(r)
+	fmt.Println(n, len(cnt))
+	for _, c := range cnt {
+		if c < 800 || c > 1200 {
+			fmt.Println("not uniform:", cnt)
+		}
+	}
+	fmt.Println(UnweightedSample(Seq(1, OneOf(2), Empty()), rnd))
+	// Output:
+	// 8 8
+	// [1 2]
+}
+
+func ExampleWalkString() {
+	r := Seq("GET", OneOf("/", "/index"), OneOf(Empty(), 200))
+	WalkString(r, " ", func(s string) { fmt.Println(s) })
+	WalkConcat(Seq(OneOf("a", "b"), 1), func(s string) { fmt.Println(s) })
+	// Output:
+	// GET /
+	// GET / 200
+	// GET /index
+	// GET /index 200
+	// a1
+	// b1
+}
+
+func ExampleWalkDistinct() {
+	r := Seq(Optional("a"), Optional("a"))
+	WalkDistinct(r, echo)
+	seen := map[string]struct{}{"a": {}}
+	WalkDistinctSet(r, seen, echo)
+	fmt.Println(len(seen))
+	// Output:
+	// []
+	// [a]
+	// [a a]
+	// []
+	// [a a]
+	// 3
+}
+
+func ExampleValidate() {
+	digit := OneOf(0, 1)
+	fmt.Println(Validate(Seq(digit, digit, OneOf(Empty(), digit))))
+
+	as := make([]Alt, 2)
+	expr := R(as...)
+	as[0], as[1] = A(V(0)), A(V("("), E(expr), V(")"))
+	fmt.Println(Validate(Seq("=", expr)))
+
+	fmt.Println(Validate(Seq(1, 2, Empty())))
+	fmt.Println(Validate(Seq("x", Times(0, "y"))))
+	fmt.Println(Validate(Seq(1, Optional(Empty()))))
+	fmt.Println(Validate(OneOf(Empty(), Seq(Times(0, "y")))))
+	// Output:
+	// <nil>
+	// rule: cyclic rule: root.alts[0].elems[1].alts[1].elems[1] refers back to root.alts[0].elems[1]
+	// <nil>
+	// <nil>
+	// <nil>
+	// rule: rule produces no value: root
+}
+
+func ExampleString() {
+	fmt.Println(String(Seq("GET", OneOf("/", "/index"), OneOf(Empty(), 200))))
+	fmt.Println(String(OneOf(Empty(), Seq(" ", ""))))
+
+	as := make([]Alt, 2)
+	expr := R(as...)
+	as[0], as[1] = A(V(0)), A(V("("), E(expr), V(")"))
+	fmt.Println(String(expr))
+	// Output:
+	// "GET" ("/" | "/index") (ε | 200)
+	// ε | " " ""
+	// 0 | "(" (...) ")"
+}
+
+func ExampleParse() {
+	r, err := Parse(`"GET" ("/" | "/index") (ε | 200 | 4.5 | true)`)
+	fmt.Println(String(r), err)
+	WalkString(r, " ", func(s string) { fmt.Println(s) })
+
+	r, err = Parse(`| "a" ()`)
+	fmt.Println(String(r), err)
+
+	for _, s := range []string{`"a" | (b)`, `("a" | "b"`, `"a") "b"`, `"a\q"`, `"a`} {
+		_, err := Parse(s)
+		fmt.Println(err)
+	}
+	// Output:
+	// "GET" ("/" | "/index") (ε | 200 | 4.5 | true) <nil>
+	// GET /
+	// GET / 200
+	// GET / 4.5
+	// GET / true
+	// GET /index
+	// GET /index 200
+	// GET /index 4.5
+	// GET /index true
+	// ε | "a" (ε) <nil>
+	// rule: syntax error at offset 7: unexpected "b", strings must be quoted
+	// rule: syntax error at offset 10: unclosed ( at offset 0
+	// rule: syntax error at offset 3: unexpected ')'
+	// rule: syntax error at offset 0: invalid string "a\q"
+	// rule: syntax error at offset 0: unterminated string
+}
+
+func ExampleWalkPrune() {
+	r := Seq(OneOf(1, 2), OneOf(3, 4), OneOf(5, 6))
+	WalkPrune(r, func(prefix []interface{}) bool {
+		return len(prefix) == 0 || prefix[0] != 2 && !(len(prefix) == 2 && prefix[1] == 4)
+	}, echo)
+	// Output:
+	// [1 3 5]
+	// [1 3 6]
+}
+
+func ExampleBetween() {
+	Walk(Seq("a", Optional("b")), echo)
+	fmt.Println(Count(Times(3, OneOf(0, 1))))
+	Walk(Between(1, 3, "x"), echo)
+	fmt.Println(Count(Plus(4, OneOf("x", "y"))))
+	fmt.Println(Count(Star(4, OneOf("x", "y"))))
+	// Output:
+	// [a]
+	// [a b]
+	// 8 <nil>
+	// [x]
+	// [x x]
+	// [x x x]
+	// 30 <nil>
+	// 31 <nil>
+}
+
+func nested(depth int) Rule {
+	r := OneOf(0, 1)
+	for i := 0; i < depth; i++ {
+		r = Seq(OneOf(r, "x", Empty()), OneOf(0, 1))
+	}
+	return r
+}
+
+func BenchmarkWalk(b *testing.B) {
+	for _, bb := range []struct {
+		name string
+		r    Rule
+	}{
+		{"Nested", nested(8)},
+		{"Long", Times(12, OneOf(0, 1, 2))},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				Walk(bb.r, func(...interface{}) {})
+			}
+		})
+	}
+}
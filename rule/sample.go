@@ -0,0 +1,65 @@
+package rule
+
+import (
+	"math/big"
+	"math/rand"
+)
+
+// Sample returns a production of root picked uniformly at random, by choosing
+// each alternative with a probability proportional to its number of
+// productions. It returns false if root is cyclic. The global source is used if
+// r is nil.
+func Sample(root Rule, r *rand.Rand) ([]interface{}, bool) {
+	c := counter{memo: make(map[interface{}]*big.Int), visiting: make(map[interface{}]bool)}
+	total, err := c.rule(root)
+	if err != nil {
+		return nil, false
+	}
+	if r == nil {
+		r = rand.New(rand.NewSource(rand.Int63()))
+	}
+	return c.atRule(root, new(big.Int).Rand(r, total), []interface{}{}), true
+}
+
+// UnweightedSample returns a random production of root by choosing each
+// alternative uniformly, which is cheaper than Sample but favors productions
+// of alternatives with fewer productions. It may not return on cyclic rules.
+// The global source is used if r is nil.
+func UnweightedSample(root Rule, r *rand.Rand) []interface{} {
+	xs := []interface{}{}
+	stack := []Elem{E(root)}
+	for len(stack) > 0 {
+		e := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if e == nil {
+			continue
+		}
+		if !e.IsRule() {
+			xs = append(xs, e.Value())
+			continue
+		}
+		if e.Rule() == nil {
+			continue
+		}
+		as := e.Rule().Alts()
+		if len(as) == 0 {
+			continue
+		}
+		a := as[randIntn(r, len(as))]
+		if a == nil {
+			continue
+		}
+		elems := a.Elems()
+		for i := len(elems) - 1; i >= 0; i-- {
+			stack = append(stack, elems[i])
+		}
+	}
+	return xs
+}
+
+func randIntn(r *rand.Rand, n int) int {
+	if r == nil {
+		return rand.Intn(n)
+	}
+	return r.Intn(n)
+}
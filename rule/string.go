@@ -0,0 +1,71 @@
+package rule
+
+import (
+	"fmt"
+	"strings"
+)
+
+// String renders root in a BNF-like notation: alternatives are separated by
+// " | ", elements by spaces, nested rules are parenthesized and an empty
+// alternative is written as ε. Strings are quoted by %q, other values are
+// formatted by %v. A rule that refers back to one of its ancestors is written
+// as (...). The output only depends on the structure of root.
+func String(root Rule) string {
+	var sb strings.Builder
+	p := printer{sb: &sb, visiting: make(map[interface{}]bool)}
+	p.rule(root)
+	return sb.String()
+}
+
+type printer struct {
+	sb       *strings.Builder
+	visiting map[interface{}]bool
+}
+
+func (p *printer) rule(r Rule) {
+	if r == nil {
+		return
+	}
+	if k, ok := identity(r); ok {
+		if p.visiting[k] {
+			p.sb.WriteString("...")
+			return
+		}
+		p.visiting[k] = true
+		defer delete(p.visiting, k)
+	}
+	for i, a := range r.Alts() {
+		if i > 0 {
+			p.sb.WriteString(" | ")
+		}
+		p.alt(a)
+	}
+}
+
+func (p *printer) alt(a Alt) {
+	var elems []Elem
+	if a != nil {
+		elems = a.Elems()
+	}
+	n := 0
+	for _, e := range elems {
+		if e == nil {
+			continue
+		}
+		if n++; n > 1 {
+			p.sb.WriteByte(' ')
+		}
+		if e.IsRule() {
+			p.sb.WriteByte('(')
+			p.rule(e.Rule())
+			p.sb.WriteByte(')')
+		} else if s, ok := e.Value().(string); ok {
+			fmt.Fprintf(p.sb, "%q", s)
+		} else {
+			fmt.Fprintf(p.sb, "%v", e.Value())
+		}
+	}
+	if n == 0 {
+		p.sb.WriteString("ε")
+	}
+}
@@ -0,0 +1,73 @@
+package rule
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrNoValue = errors.New("rule: rule produces no value")
+
+// Validate checks the rule graph of root before walking it. It returns an
+// error wrapping ErrCycle if a rule references itself, directly or indirectly,
+// as Walk would never end then; rules shared by several others are fine. It
+// returns an error wrapping ErrNoValue if root has no production with a value,
+// which is usually a mistake. Nested rules that produce nothing on their own,
+// like Empty() or Times(0, x), are fine. Cycles are located by the path of the
+// offending rule from root, like root.alts[1].elems[0].
+func Validate(root Rule) error {
+	if root == nil {
+		return nil
+	}
+	v := validator{done: make(map[interface{}]bool), visiting: make(map[interface{}]string)}
+	produces, err := v.rule(root, "root")
+	if err == nil && !produces {
+		err = fmt.Errorf("%w: root", ErrNoValue)
+	}
+	return err
+}
+
+type validator struct {
+	done     map[interface{}]bool
+	visiting map[interface{}]string
+}
+
+func (v *validator) rule(r Rule, path string) (bool, error) {
+	if r == nil {
+		return false, nil
+	}
+	k, ok := identity(r)
+	if ok {
+		if produces, ok := v.done[k]; ok {
+			return produces, nil
+		}
+		if p, ok := v.visiting[k]; ok {
+			return false, fmt.Errorf("%w: %s refers back to %s", ErrCycle, path, p)
+		}
+		v.visiting[k] = path
+		defer delete(v.visiting, k)
+	}
+	produces := false
+	for i, a := range r.Alts() {
+		if a == nil {
+			continue
+		}
+		for j, e := range a.Elems() {
+			if e == nil {
+				continue
+			}
+			if !e.IsRule() {
+				produces = true
+				continue
+			}
+			p, err := v.rule(e.Rule(), fmt.Sprintf("%s.alts[%d].elems[%d]", path, i, j))
+			if err != nil {
+				return false, err
+			}
+			produces = produces || p
+		}
+	}
+	if ok {
+		v.done[k] = produces
+	}
+	return produces, nil
+}